@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+)
+
+func main() {
+	schemaDir := flag.String("schemas", "schemas", "Directory with the schemas to check")
+	baselineDir := flag.String("baseline", "", "Directory with the schemas currently deployed, e.g. from the target branch")
+	mode := flag.String("mode", string(events.CompatibilityBackward), "Default compatibility mode: BACKWARD, FORWARD, FULL or NONE")
+	overrides := flag.String("overrides", "", "Per event type modes, e.g. user.created=FULL,order.placed=NONE")
+	flag.Parse()
+
+	candidate, err := newRegistry(*mode, *overrides)
+	if err != nil {
+		log.Fatalf("invalid compatibility settings: %v", err)
+	}
+
+	// Loading checks every version against the one before it.
+	if err := candidate.LoadDir(*schemaDir); err != nil {
+		log.Fatalf("%s: %v", *schemaDir, err)
+	}
+
+	var problems []string
+	if *baselineDir != "" {
+		problems, err = compareBaseline(candidate, *baselineDir)
+		if err != nil {
+			log.Fatalf("%s: %v", *baselineDir, err)
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d schema version(s) in %s are compatible\n", len(candidate.Schemas()), *schemaDir)
+}
+
+func newRegistry(mode, overrides string) (*events.SchemaRegistry, error) {
+	defaultMode, err := events.ParseCompatibilityMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	modes, err := events.ParseCompatibilityOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := events.NewSchemaRegistry()
+	registry.SetCompatibility("", defaultMode)
+	for eventType, m := range modes {
+		registry.SetCompatibility(eventType, m)
+	}
+	return registry, nil
+}
+
+// compareBaseline checks that no deployed file schema was removed or
+// changed in place in a way its event type's mode does not allow.
+func compareBaseline(candidate *events.SchemaRegistry, dir string) ([]string, error) {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("baseline %s does not exist, skipping the comparison\n", dir)
+		return nil, nil
+	}
+
+	baseline := events.NewSchemaRegistry()
+	baseline.SetCompatibility("", events.CompatibilityNone)
+	if err := baseline.LoadDir(dir); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, info := range baseline.Schemas() {
+		if info.Source == "go" {
+			continue
+		}
+
+		previous, err := baseline.Shape(info.EventType, info.Version)
+		if err != nil {
+			return nil, err
+		}
+		next, err := candidate.Shape(info.EventType, info.Version)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s: version was removed", info.EventType, info.Version))
+			continue
+		}
+
+		mode := candidate.Compatibility(info.EventType)
+		for _, issue := range events.CheckCompatibility(mode, previous, next) {
+			problems = append(problems, fmt.Sprintf("%s %s: %s: %s (changed in place, %s)", info.EventType, info.Version, issue.Path, issue.Reason, mode))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+)
+
+const asyncAPIVersion = "3.0.0"
+
+func main() {
+	schemaDir := flag.String("schemas", "schemas", "Directory with JSON Schema files to export with the Go schemas; empty for Go schemas only")
+	outDir := flag.String("out", "docs/events", "Directory the AsyncAPI document and JSON Schemas are written to")
+	title := flag.String("title", "Event Processor", "AsyncAPI info title")
+	version := flag.String("version", "1.0.0", "AsyncAPI info version")
+	flag.Parse()
+
+	registry := events.NewSchemaRegistry()
+	if *schemaDir != "" {
+		if err := registry.LoadDir(*schemaDir); err != nil {
+			log.Fatalf("%s: %v", *schemaDir, err)
+		}
+	}
+
+	schemasDir := filepath.Join(*outDir, "schemas")
+	if err := os.RemoveAll(schemasDir); err != nil {
+		log.Fatalf("failed to clean %s: %v", schemasDir, err)
+	}
+
+	infos := registry.Schemas()
+	for _, info := range infos {
+		data, err := registry.JSONSchema(info.EventType, info.Version)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := writeFile(filepath.Join(schemasDir, info.EventType, info.Version+".json"), data); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(asyncAPIDocument(*title, *version, infos), "", "  ")
+	if err != nil {
+		log.Fatalf("failed to marshal AsyncAPI document: %v", err)
+	}
+	if err := writeFile(filepath.Join(*outDir, "asyncapi.json"), append(data, '\n')); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	fmt.Printf("Exported %d schema version(s) to %s\n", len(infos), *outDir)
+}
+
+// asyncAPIDocument describes the queue as one channel carrying a message per
+// event type and version. Message payloads are the envelope, with the body
+// pointing at the exported JSON Schema of the version.
+func asyncAPIDocument(title, version string, infos []events.SchemaInfo) map[string]any {
+	messages := map[string]any{}
+	refs := map[string]any{}
+	for _, info := range infos {
+		name := info.EventType + "." + info.Version
+		messages[name] = map[string]any{
+			"name":        name,
+			"title":       info.EventType + " " + info.Version,
+			"contentType": "application/json",
+			"payload":     envelopeSchema(info),
+		}
+		refs[name] = map[string]any{"$ref": "#/components/messages/" + name}
+	}
+
+	return map[string]any{
+		"asyncapi": asyncAPIVersion,
+		"info": map[string]any{
+			"title":   title,
+			"version": version,
+		},
+		"defaultContentType": "application/json",
+		"channels": map[string]any{
+			"events": map[string]any{
+				"address":  "events-main",
+				"messages": refs,
+			},
+		},
+		"operations": map[string]any{
+			"processEvents": map[string]any{
+				"action":  "receive",
+				"channel": map[string]any{"$ref": "#/channels/events"},
+			},
+		},
+		"components": map[string]any{
+			"messages": messages,
+		},
+	}
+}
+
+func envelopeSchema(info events.SchemaInfo) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"required": []string{
+			"body", "client_id", "event_id", "event_type", "occurred_at", "schema_version", "tenant_id",
+		},
+		"properties": map[string]any{
+			"event_id":       map[string]any{"type": "string"},
+			"event_type":     map[string]any{"type": "string", "const": info.EventType},
+			"tenant_id":      map[string]any{"type": "string"},
+			"client_id":      map[string]any{"type": "string"},
+			"schema_version": map[string]any{"type": "string", "const": info.Version},
+			"occurred_at":    map[string]any{"type": "string", "format": "date-time"},
+			"content_type":   map[string]any{"type": "string", "enum": info.ContentTypes},
+			"body":           map[string]any{"$ref": "./schemas/" + info.EventType + "/" + info.Version + ".json"},
+		},
+	}
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
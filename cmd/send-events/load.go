@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/guilherme-daniel-rs/event-processor/internal/fakedata"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ratelimit"
+)
+
+// maxBatchSize is the most entries SendMessageBatch accepts.
+const maxBatchSize = 10
+
+type mixEntry struct {
+	eventType string
+	version   string
+	weight    int
+	codec     events.Codec
+}
+
+// parseMix reads weights written as "user.created=3,order.placed:v2=1".
+// Entries without a version use defaultVersion.
+func parseMix(s, defaultVersion string, registry *events.SchemaRegistry, format string) ([]mixEntry, error) {
+	var entries []mixEntry
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		selector, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(selector) == "" {
+			return nil, fmt.Errorf("invalid mix entry: %s", entry)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %s", selector, value)
+		}
+
+		eventType, version, ok := strings.Cut(strings.TrimSpace(selector), ":")
+		if !ok {
+			version = defaultVersion
+		}
+		codec, err := registry.Codec(eventType, version, format)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, mixEntry{eventType: eventType, version: version, weight: weight, codec: codec})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no event types in %q", s)
+	}
+	return entries, nil
+}
+
+func pick(entries []mixEntry) mixEntry {
+	total := 0
+	for _, entry := range entries {
+		total += entry.weight
+	}
+
+	n := gofakeit.Number(1, total)
+	for _, entry := range entries {
+		if n <= entry.weight {
+			return entry
+		}
+		n -= entry.weight
+	}
+	return entries[len(entries)-1]
+}
+
+type loadOptions struct {
+	count        int
+	rate         float64
+	duration     time.Duration
+	concurrency  int
+	batch        int
+	padding      int
+	invalidRatio float64
+}
+
+type loadStats struct {
+	mu        sync.Mutex
+	started   time.Time
+	elapsed   time.Duration
+	sent      int
+	errors    int
+	latencies []time.Duration
+}
+
+func (s *loadStats) record(latency time.Duration, sent, errors int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies = append(s.latencies, latency)
+	s.sent += sent
+	s.errors += errors
+}
+
+func (s *loadStats) fail(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors += n
+}
+
+func (s *loadStats) progress() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.started)
+	return fmt.Sprintf("%6s  sent %d  errors %d  %.1f msg/s",
+		elapsed.Truncate(time.Second), s.sent, s.errors, float64(s.sent)/elapsed.Seconds())
+}
+
+// print reports the achieved rate and the latency of send calls; with
+// batches, a latency covers the whole batch.
+func (s *loadStats) print() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+
+	fmt.Printf("\nSent %d messages in %s (%.1f msg/s), %d errors\n",
+		s.sent, s.elapsed.Truncate(time.Millisecond), float64(s.sent)/s.elapsed.Seconds(), s.errors)
+	if len(s.latencies) == 0 {
+		return
+	}
+	fmt.Printf("Send latency: p50 %s  p90 %s  p99 %s  max %s\n",
+		percentile(s.latencies, 50), percentile(s.latencies, 90), percentile(s.latencies, 99), s.latencies[len(s.latencies)-1])
+}
+
+// percentile expects sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(0, i)].Round(time.Microsecond)
+}
+
+// runLoad sends generated messages from opts.concurrency workers until
+// opts.duration has passed or, without a duration, opts.count messages
+// were sent. A shared token bucket keeps the total to opts.rate.
+func runLoad(ctx context.Context, s *sender, generator *fakedata.Generator, mix []mixEntry, opts loadOptions) *loadStats {
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{Rate: opts.rate, Burst: opts.batch}, nil)
+	stats := &loadStats{started: time.Now()}
+
+	remaining := int64(opts.count)
+	claim := func() int {
+		if opts.duration > 0 {
+			return opts.batch
+		}
+		for {
+			left := atomic.LoadInt64(&remaining)
+			n := min(left, int64(opts.batch))
+			if n <= 0 {
+				return 0
+			}
+			if atomic.CompareAndSwapInt64(&remaining, left, left-n) {
+				return int(n)
+			}
+		}
+	}
+
+	if opts.duration > 0 {
+		fmt.Printf("Sending for %s to queue: %s with %d sender(s)\n", opts.duration, s.queueURL, opts.concurrency)
+	} else {
+		fmt.Printf("Sending %d messages to queue: %s with %d sender(s)\n", opts.count, s.queueURL, opts.concurrency)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fmt.Println(stats.progress())
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				n := claim()
+				if n == 0 {
+					return
+				}
+				if !waitTokens(ctx, limiter, n) {
+					return
+				}
+				sendLoad(ctx, s, generator, mix, opts, n, stats)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+
+	stats.elapsed = time.Since(stats.started)
+	return stats
+}
+
+func waitTokens(ctx context.Context, limiter *ratelimit.Limiter, n int) bool {
+	for i := 0; i < n; i++ {
+		for {
+			ok, wait := limiter.Allow("load")
+			if ok {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(wait):
+			}
+		}
+	}
+	return true
+}
+
+type loadMessage struct {
+	envelope []byte
+	tenantID string
+	eventID  string
+}
+
+func sendLoad(ctx context.Context, s *sender, generator *fakedata.Generator, mix []mixEntry, opts loadOptions, n int, stats *loadStats) {
+	messages := make([]loadMessage, 0, n)
+	for i := 0; i < n; i++ {
+		entry := pick(mix)
+		body, _, err := fakeBody(generator, entry.eventType, entry.version, opts.invalidRatio)
+		if err != nil {
+			log.Printf("Failed to generate %s %s body: %v", entry.eventType, entry.version, err)
+			stats.fail(1)
+			continue
+		}
+		message, err := createMessage(entry.eventType, entry.version, body, opts.padding, entry.codec)
+		if err != nil {
+			log.Printf("Failed to encode %s %s body: %v", entry.eventType, entry.version, err)
+			stats.fail(1)
+			continue
+		}
+		envelope, _ := json.Marshal(message)
+		messages = append(messages, loadMessage{envelope: envelope, tenantID: message.TenantID, eventID: message.EventID})
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	start := time.Now()
+	var failed int
+	var err error
+	if opts.batch == 1 {
+		err = s.send(ctx, messages[0].envelope, messages[0].tenantID, messages[0].eventID)
+	} else {
+		failed, err = s.sendBatch(ctx, messages)
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Cut short by the end of the run, not a failure of the queue.
+	case err != nil:
+		log.Printf("Failed to send %d message(s): %v", len(messages), err)
+		stats.record(time.Since(start), 0, len(messages))
+	default:
+		stats.record(time.Since(start), len(messages)-failed, failed)
+	}
+}
+
+// sendBatch sends up to maxBatchSize messages in one call and returns how
+// many of them SQS rejected.
+func (s *sender) sendBatch(ctx context.Context, messages []loadMessage) (int, error) {
+	sentAt := time.Now()
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(messages))
+	for i, message := range messages {
+		input, err := s.input(ctx, message.envelope, message.tenantID, message.eventID, sentAt)
+		if err != nil {
+			return 0, err
+		}
+		entries = append(entries, types.SendMessageBatchRequestEntry{
+			Id:                     aws.String(strconv.Itoa(i)),
+			MessageBody:            input.MessageBody,
+			MessageAttributes:      input.MessageAttributes,
+			MessageGroupId:         input.MessageGroupId,
+			MessageDeduplicationId: input.MessageDeduplicationId,
+		})
+	}
+
+	out, err := s.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(s.queueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, success := range out.Successful {
+		if i, err := strconv.Atoi(aws.ToString(success.Id)); err == nil && i < len(messages) {
+			s.sent.add(messages[i].eventID, sentAt)
+		}
+	}
+	for _, failure := range out.Failed {
+		log.Printf("Failed to send batch entry %s: %s", aws.ToString(failure.Id), aws.ToString(failure.Message))
+	}
+	return len(out.Failed), nil
+}
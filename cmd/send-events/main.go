@@ -1,19 +1,37 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
+	dynamodbadapter "github.com/guilherme-daniel-rs/event-processor/internal/adapters/dynamodb"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/s3blob"
+	"github.com/guilherme-daniel-rs/event-processor/internal/app"
+	"github.com/guilherme-daniel-rs/event-processor/internal/claimcheck"
 	"github.com/guilherme-daniel-rs/event-processor/internal/config"
+	"github.com/guilherme-daniel-rs/event-processor/internal/contentencoding"
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/guilherme-daniel-rs/event-processor/internal/fakedata"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/klauspost/compress/zstd"
 )
 
 func init() {
@@ -27,6 +45,7 @@ type MessageHeader struct {
 	ClientID      string          `json:"client_id"`
 	SchemaVersion string          `json:"schema_version"`
 	OccurredAt    string          `json:"occurred_at"`
+	ContentType   string          `json:"content_type,omitempty"`
 	Body          json.RawMessage `json:"body"`
 }
 
@@ -34,8 +53,27 @@ func main() {
 	count := flag.Int("count", 1, "Number of messages to send")
 	eventType := flag.String("type", "user.created", "Event type")
 	schemaVersion := flag.String("version", "v1", "Schema version")
+	fifo := flag.Bool("fifo", false, "Send to a FIFO queue, grouping messages by tenant")
+	format := flag.String("format", "json", "Body format: json, protobuf or avro")
+	encoding := flag.String("encoding", "", "Compress bodies with gzip or zstd and base64 them")
+	padding := flag.Int("padding", 0, "Bytes of padding added to each body; bodies over 256 KB are offloaded to S3")
+	invalidRatio := flag.Float64("invalid-ratio", 0, "Share of messages, from 0 to 1, sent with one field broken on purpose")
+	file := flag.String("file", "", "Replay the envelopes of a JSONL file instead of generating messages")
+	fresh := flag.Bool("fresh", false, "With -file, replace each envelope's event_id and occurred_at with new values")
+	speed := flag.Float64("speed", 0, "With -file, keep the gaps between occurred_at values divided by this factor; 0 sends as fast as possible")
+	rate := flag.Float64("rate", 0, "Target messages per second; 0 sends as fast as possible")
+	duration := flag.Duration("duration", 0, "Keep sending for this long instead of stopping after -count messages")
+	concurrency := flag.Int("concurrency", 1, "Number of concurrent senders")
+	batch := flag.Int("batch", 1, "Messages per SendMessageBatch call, up to 10")
+	mix := flag.String("mix", "", "Event types sent by weight, e.g. user.created=3,order.placed:v1=1; defaults to -type")
+	verify := flag.Bool("verify", false, "After sending, poll the events table for every event_id and report lost, duplicated and failed events")
+	verifyTimeout := flag.Duration("verify-timeout", 30*time.Second, "With -verify, how long to wait for events to be stored")
 	flag.Parse()
 
+	if *invalidRatio < 0 || *invalidRatio > 1 {
+		log.Fatalf("invalid-ratio must be between 0 and 1, got %v", *invalidRatio)
+	}
+
 	ctx := context.Background()
 
 	cfg, err := awsconfig.LoadDefaultConfig(
@@ -55,41 +93,244 @@ func main() {
 		o.BaseEndpoint = aws.String(config.Get().AWS.Endpoint)
 	})
 
+	blobStore := s3blob.NewBlobStore(s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(config.Get().AWS.Endpoint)
+		o.UsePathStyle = true
+	}))
+
+	registry := events.NewSchemaRegistry()
+	if dir := config.Get().Schema.Dir; dir != "" {
+		if err := registry.LoadDir(dir); err != nil {
+			log.Fatalf("failed to load schemas from %s: %v", dir, err)
+		}
+	}
+
 	queueURL := config.Get().SQS.QueueURL
+	sender := &sender{
+		client:   sqsClient,
+		blobs:    blobStore,
+		queueURL: queueURL,
+		bucket:   config.Get().Blob.Bucket,
+		encoding: *encoding,
+		fifo:     *fifo,
+	}
 
-	fmt.Printf("Sending %d message to queue: %s, eventyType: %s, version: %s\n", *count, queueURL, *eventType, *schemaVersion)
+	if *verify {
+		sender.sent = &sentEvents{at: make(map[string]time.Time)}
+	}
 
-	for i := 0; i < *count; i++ {
-		message := createMessage(*eventType, *schemaVersion)
+	generator := fakedata.New(registry, 0)
+	opts := loadOptions{
+		count:        *count,
+		rate:         *rate,
+		duration:     *duration,
+		concurrency:  max(1, *concurrency),
+		batch:        *batch,
+		padding:      *padding,
+		invalidRatio: *invalidRatio,
+	}
 
-		messageBody, _ := json.Marshal(message)
+	switch {
+	case *file != "":
+		if err := replay(ctx, sender, *file, replayOptions{fresh: *fresh, speed: *speed}); err != nil {
+			log.Fatalf("failed to replay %s: %v", *file, err)
+		}
+	case *rate > 0 || *duration > 0 || *concurrency > 1 || *batch > 1 || *mix != "":
+		if *mix == "" {
+			*mix = *eventType + ":" + *schemaVersion + "=1"
+		}
+		entries, err := parseMix(*mix, *schemaVersion, registry, *format)
+		if err != nil {
+			log.Fatalf("invalid mix: %v", err)
+		}
+		if *batch < 1 || *batch > maxBatchSize {
+			log.Fatalf("batch must be between 1 and %d, got %d", maxBatchSize, *batch)
+		}
+		runLoad(ctx, sender, generator, entries, opts).print()
+	default:
+		codec, err := registry.Codec(*eventType, *schemaVersion, *format)
+		if err != nil {
+			log.Fatalf("invalid body format: %v", err)
+		}
+		sendCount(ctx, sender, generator, mixEntry{eventType: *eventType, version: *schemaVersion, codec: codec}, opts)
+	}
+
+	if *verify {
+		finder := dynamodbadapter.NewEventRepository(dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+			o.BaseEndpoint = aws.String(config.Get().AWS.Endpoint)
+		}))
+		verifyEvents(ctx, finder, sender.sent.snapshot(), *verifyTimeout).print()
+	}
+}
+
+func sendCount(ctx context.Context, s *sender, generator *fakedata.Generator, entry mixEntry, opts loadOptions) {
+	fmt.Printf("Sending %d message to queue: %s, eventyType: %s, version: %s\n", opts.count, s.queueURL, entry.eventType, entry.version)
+
+	for i := 0; i < opts.count; i++ {
+		body, issue, err := fakeBody(generator, entry.eventType, entry.version, opts.invalidRatio)
+		if err != nil {
+			log.Fatalf("failed to generate body: %v", err)
+		}
 
-		_, err = sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
-			QueueUrl:    aws.String(queueURL),
-			MessageBody: aws.String(string(messageBody)),
-		})
+		message, err := createMessage(entry.eventType, entry.version, body, opts.padding, entry.codec)
 		if err != nil {
+			log.Fatalf("failed to encode body: %v", err)
+		}
+
+		messageBody, _ := json.Marshal(message)
+
+		if err := s.send(ctx, messageBody, message.TenantID, message.EventID); err != nil {
 			log.Printf("Failed to send message %d: %v", i, err)
 			continue
 		}
 
+		if issue != nil {
+			fmt.Printf("Message %d sent successfully - Event ID: %s (invalid: %s %s)\n", i+1, message.EventID, issue.Path, issue.Rule)
+			continue
+		}
 		fmt.Printf("Message %d sent successfully - Event ID: %s\n", i+1, message.EventID)
 	}
 
-	fmt.Printf("\nSuccessfully sent %d messages!\n", *count)
+	fmt.Printf("\nSuccessfully sent %d messages!\n", opts.count)
 }
 
-func createMessage(eventType, schemaVersion string) MessageHeader {
-	eventID := fmt.Sprintf("evt-%d-%d", time.Now().Unix(), gofakeit.Number(1, 9999))
+type sender struct {
+	client   *sqs.Client
+	blobs    ports.BlobStore
+	queueURL string
+	bucket   string
+	encoding string
+	fifo     bool
+	// sent records what was sent for -verify; nil when not verifying.
+	sent *sentEvents
+}
+
+// send encodes an envelope, offloads it to S3 when it is too large and
+// sends it, grouped by tenant on FIFO queues.
+func (s *sender) send(ctx context.Context, envelope []byte, tenantID, eventID string) error {
+	sentAt := time.Now()
+	input, err := s.input(ctx, envelope, tenantID, eventID, sentAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.client.SendMessage(ctx, input); err != nil {
+		return err
+	}
+	s.sent.add(eventID, sentAt)
+	return nil
+}
+
+// input builds the request for an envelope, stamped with sentAt for the
+// worker's ingest lag.
+func (s *sender) input(ctx context.Context, envelope []byte, tenantID, eventID string, sentAt time.Time) (*sqs.SendMessageInput, error) {
+	messageBody, err := encodeBody(envelope, s.encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	messageBody, attrs, err := claimcheck.Offload(ctx, s.blobs, s.bucket, messageBody, claimcheck.MaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(s.queueURL),
+		MessageBody:       aws.String(string(messageBody)),
+		MessageAttributes: numberAttributes(attrs),
+	}
+	if input.MessageAttributes == nil {
+		input.MessageAttributes = map[string]types.MessageAttributeValue{}
+	}
+	input.MessageAttributes[app.SentAtAttribute] = types.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.FormatInt(sentAt.UnixMilli(), 10)),
+	}
+	if s.encoding != "" {
+		input.MessageAttributes["content-encoding"] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(s.encoding),
+		}
+	}
+	if s.fifo {
+		input.MessageGroupId = aws.String(tenantID)
+		input.MessageDeduplicationId = aws.String(uuid.NewString())
+	}
+	return input, nil
+}
+
+func encodeBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch encoding {
+	case "":
+		return body, nil
+	case contentencoding.Gzip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case contentencoding.Zstd:
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(w.EncodeAll(body, nil))
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+func numberAttributes(attrs map[string]string) map[string]types.MessageAttributeValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	values := make(map[string]types.MessageAttributeValue, len(attrs))
+	for name, value := range attrs {
+		values[name] = types.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(value),
+		}
+	}
+	return values
+}
+
+func newEventID() string {
+	return uuid.NewString()
+}
+
+// fakeBody generates a valid body, or with probability invalidRatio one with
+// a broken field, returned with the issue the worker should report.
+func fakeBody(generator *fakedata.Generator, eventType, version string, invalidRatio float64) (map[string]any, *events.ValidationIssue, error) {
+	if invalidRatio > 0 && gofakeit.Float64() < invalidRatio {
+		body, issue, err := generator.Invalid(eventType, version)
+		return body, &issue, err
+	}
+
+	body, err := generator.Valid(eventType, version)
+	return body, nil, err
+}
+
+func createMessage(eventType, schemaVersion string, body map[string]any, padding int, codec events.Codec) (MessageHeader, error) {
+	eventID := newEventID()
 	occurredAt := time.Now().Format(time.RFC3339)
 
 	tenantID := fmt.Sprintf("tenant-%d", gofakeit.Number(1, 10))
 	clientID := fmt.Sprintf("client-%d", gofakeit.Number(1, 100))
 
-	var fakeBody any
-	fakeBody = getFakeData(eventType)
+	if padding > 0 {
+		body["padding"] = strings.Repeat("x", padding)
+	}
 
-	bodyJSON, _ := json.Marshal(fakeBody)
+	bodyJSON, contentType, err := encodeEventBody(body, codec)
+	if err != nil {
+		return MessageHeader{}, err
+	}
 
 	return MessageHeader{
 		EventID:       eventID,
@@ -98,23 +339,24 @@ func createMessage(eventType, schemaVersion string) MessageHeader {
 		ClientID:      clientID,
 		SchemaVersion: schemaVersion,
 		OccurredAt:    occurredAt,
+		ContentType:   contentType,
 		Body:          bodyJSON,
-	}
+	}, nil
 }
 
-func getFakeData(eventType string) map[string]any {
-	switch eventType {
-	case "payment.processed":
-		return map[string]any{
-			"payment_id":     fmt.Sprintf("pay-%d", gofakeit.Number(1000, 9999)),
-			"order_id":       fmt.Sprintf("order-%d", gofakeit.Number(1000, 9999)),
-			"amount":         99.99 + float64(gofakeit.Number(0, 9999)),
-			"payment_method": "credit_card",
-			"status":         "success",
-		}
-	default:
-		return map[string]any{
-			"message": fmt.Sprintf("Event %d", gofakeit.Number(1000, 9999)),
-		}
+// encodeEventBody embeds binary formats in the JSON envelope as a base64
+// string and names them in content_type.
+func encodeEventBody(body any, codec events.Codec) (json.RawMessage, string, error) {
+	if codec.ContentType() == events.ContentTypeJSON {
+		data, err := json.Marshal(body)
+		return data, "", err
 	}
+
+	encoded, err := codec.Encode(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := json.Marshal(encoded)
+	return data, codec.ContentType(), err
 }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Placeholders replaced in every replayed line, so a file can be sent more
+// than once without the worker dropping the events as duplicates.
+const (
+	eventIDPlaceholder    = "{{event_id}}"
+	occurredAtPlaceholder = "{{occurred_at}}"
+)
+
+// maxLineSize fits envelopes large enough to be offloaded to S3.
+const maxLineSize = 10 << 20
+
+type replayOptions struct {
+	fresh bool
+	speed float64
+}
+
+// replay sends the envelope on each line of path. A line that cannot be
+// parsed or sent is reported and skipped; only failing to read the file
+// stops the run.
+func replay(ctx context.Context, s *sender, path string, opts replayOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var (
+		sent, failed int
+		clock        replayClock
+	)
+	fmt.Printf("Replaying %s to queue: %s\n", path, s.queueURL)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		envelope, eventID, tenantID, occurredAt, err := prepareLine(text, opts.fresh)
+		if err != nil {
+			log.Printf("Line %d: %v", line, err)
+			failed++
+			continue
+		}
+
+		if opts.speed > 0 {
+			clock.wait(ctx, occurredAt, opts.speed)
+		}
+
+		if err := s.send(ctx, envelope, tenantID, eventID); err != nil {
+			log.Printf("Line %d: failed to send message: %v", line, err)
+			failed++
+			continue
+		}
+		sent++
+		fmt.Printf("Line %d sent successfully - Event ID: %s\n", line, eventID)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nReplayed %d messages, %d failed\n", sent, failed)
+	return nil
+}
+
+// prepareLine fills the placeholders of a line and, when fresh is set,
+// replaces its event_id and occurred_at. The occurred_at returned is the
+// one from the file, used for timing.
+func prepareLine(text string, fresh bool) ([]byte, string, string, time.Time, error) {
+	text = strings.ReplaceAll(text, eventIDPlaceholder, newEventID())
+	text = strings.ReplaceAll(text, occurredAtPlaceholder, time.Now().UTC().Format(time.RFC3339Nano))
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &envelope); err != nil {
+		return nil, "", "", time.Time{}, fmt.Errorf("invalid envelope: %w", err)
+	}
+
+	var eventID, tenantID, occurredAt string
+	for name, target := range map[string]*string{"event_id": &eventID, "tenant_id": &tenantID, "occurred_at": &occurredAt} {
+		if raw, ok := envelope[name]; ok {
+			if err := json.Unmarshal(raw, target); err != nil {
+				return nil, "", "", time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+	original, _ := time.Parse(time.RFC3339Nano, occurredAt)
+
+	if !fresh {
+		return []byte(text), eventID, tenantID, original, nil
+	}
+
+	eventID = newEventID()
+	envelope["event_id"], _ = json.Marshal(eventID)
+	envelope["occurred_at"], _ = json.Marshal(time.Now().UTC().Format(time.RFC3339Nano))
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, "", "", time.Time{}, err
+	}
+	return data, eventID, tenantID, original, nil
+}
+
+// replayClock spaces messages like their occurred_at values, measured from
+// the first message, so delays do not add up over a long file.
+type replayClock struct {
+	first time.Time
+	start time.Time
+}
+
+func (c *replayClock) wait(ctx context.Context, occurredAt time.Time, speed float64) {
+	if occurredAt.IsZero() {
+		return
+	}
+	if c.first.IsZero() {
+		c.first, c.start = occurredAt, time.Now()
+		return
+	}
+
+	offset := time.Duration(float64(occurredAt.Sub(c.first)) / speed)
+	delay := time.Until(c.start.Add(offset))
+	if delay <= 0 {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+// sentEvents remembers when each event_id was sent. A nil *sentEvents
+// ignores adds, so senders can record unconditionally.
+type sentEvents struct {
+	mu sync.Mutex
+	at map[string]time.Time
+}
+
+func (s *sentEvents) add(eventID string, sentAt time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.at[eventID] = sentAt
+}
+
+func (s *sentEvents) snapshot() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.at)
+}
+
+type verifyReport struct {
+	total      int
+	stored     int
+	lost       int
+	duplicated int
+	failed     int
+	latencies  []time.Duration
+}
+
+// verifyEvents polls the events table until every sent event_id has a
+// record or timeout passes. Events never found count as lost. Latency runs
+// from the send to the processed_at of the first record.
+func verifyEvents(ctx context.Context, finder ports.EventFinder, sent map[string]time.Time, timeout time.Duration) *verifyReport {
+	report := &verifyReport{total: len(sent)}
+	pending := maps.Clone(sent)
+	deadline := time.Now().Add(timeout)
+
+	fmt.Printf("\nVerifying %d events, waiting up to %s\n", len(sent), timeout)
+
+	for len(pending) > 0 {
+		for eventID, sentAt := range pending {
+			records, err := finder.FindByEventID(ctx, eventID)
+			if err != nil {
+				log.Printf("Failed to look up event %s: %v", eventID, err)
+				continue
+			}
+			if len(records) == 0 {
+				continue
+			}
+
+			delete(pending, eventID)
+			report.stored++
+			if len(records) > 1 {
+				report.duplicated++
+			}
+
+			for _, record := range records {
+				if record.Status == "failed" {
+					report.failed++
+					break
+				}
+			}
+
+			var first time.Time
+			for _, record := range records {
+				processedAt, err := time.Parse(time.RFC3339Nano, record.ProcessedAt)
+				if err == nil && (first.IsZero() || processedAt.Before(first)) {
+					first = processedAt
+				}
+			}
+			if !first.IsZero() {
+				report.latencies = append(report.latencies, first.Sub(sentAt))
+			}
+		}
+
+		if len(pending) == 0 || time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			report.lost = len(pending)
+			return report
+		case <-time.After(time.Second):
+		}
+	}
+
+	report.lost = len(pending)
+	return report
+}
+
+func (r *verifyReport) print() {
+	fmt.Printf("Verified %d events: %d stored, %d lost, %d duplicated, %d failed\n",
+		r.total, r.stored, r.lost, r.duplicated, r.failed)
+	if len(r.latencies) == 0 {
+		return
+	}
+
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+	fmt.Printf("End-to-end latency: p50 %s  p90 %s  p99 %s  max %s\n",
+		percentile(r.latencies, 50), percentile(r.latencies, 90), percentile(r.latencies, 99), r.latencies[len(r.latencies)-1])
+}
@@ -2,19 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	dynamodbadapter "github.com/guilherme-daniel-rs/event-processor/internal/adapters/dynamodb"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/fsblob"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/httpapi"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/s3blob"
 	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/sqsconsumer"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adaptive"
 	"github.com/guilherme-daniel-rs/event-processor/internal/app"
+	"github.com/guilherme-daniel-rs/event-processor/internal/circuitbreaker"
 	"github.com/guilherme-daniel-rs/event-processor/internal/config"
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ratelimit"
 )
 
 func init() {
@@ -43,25 +60,233 @@ func main() {
 		o.BaseEndpoint = aws.String(localstackEndpoint)
 	})
 
-	sqsConsumer := sqsconsumer.NewSqsConsumer(sqsClient, sqsconsumer.Options{
-		QueueURL:    config.Get().SQS.QueueURL,
-		MaxMessages: config.Get().SQS.MaxMessages,
-		WaitTimeSec: config.Get().SQS.WaitTimeSec,
+	var blobStore ports.BlobStore
+	if dir := config.Get().Blob.LocalDir; dir != "" {
+		blobStore = fsblob.NewBlobStore(dir)
+	} else {
+		blobStore = s3blob.NewBlobStore(s3.NewFromConfig(cfg, func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(localstackEndpoint)
+			o.UsePathStyle = true
+		}))
+	}
+
+	queues, err := config.Get().SQS.Queues()
+	if err != nil {
+		log.Fatalf("failed to load queue config: %v", err)
+	}
+
+	var eventRepository ports.EventRepository = dynamodbadapter.NewEventRepository(dynamoDBClient)
+
+	var breaker *circuitbreaker.Breaker
+	receiveLimit := func() int { return -1 }
+	if breakerCfg := config.Get().Breaker; breakerCfg.Enabled {
+		breaker = circuitbreaker.NewBreaker(circuitbreaker.Options{
+			Name:             "repository",
+			FailureThreshold: breakerCfg.FailureThreshold,
+			SuccessThreshold: breakerCfg.SuccessThreshold,
+			OpenTimeout:      time.Duration(breakerCfg.OpenTimeoutSec) * time.Second,
+			MaxProbes:        breakerCfg.MaxProbes,
+		})
+		eventRepository = circuitbreaker.NewRepository(eventRepository, breaker)
+		receiveLimit = breaker.Available
+	}
+
+	schemaRegistry, err := newSchemaRegistry()
+	if err != nil {
+		log.Fatalf("failed to set up schemas: %v", err)
+	}
+
+	processor := app.NewProcessorWithOptions(eventRepository, app.Options{
+		Registry:     schemaRegistry,
+		MaxClockSkew: time.Duration(config.Get().Event.MaxClockSkewSec) * time.Second,
+		MaxEventAge:  time.Duration(config.Get().Event.MaxAgeSec) * time.Second,
 	})
+	process := processor.Process
+	if adaptiveCfg := config.Get().Adaptive; adaptiveCfg.Enabled {
+		process = adaptive.NewLimiter(adaptive.Options{
+			Name:             "process",
+			Initial:          adaptiveCfg.Initial,
+			Min:              adaptiveCfg.Min,
+			Max:              adaptiveCfg.Max,
+			BackoffRatio:     adaptiveCfg.BackoffRatio,
+			LatencyTolerance: adaptiveCfg.LatencyTolerance,
+		}).Wrap(process)
+	}
 
-	eventRepository := dynamodbadapter.NewEventRepository(dynamoDBClient)
-	processor := app.NewProcessor(eventRepository)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
+	if dir := config.Get().Schema.Dir; dir != "" && config.Get().Schema.Watch {
+		err := schemaRegistry.Watch(ctx, dir, func(err error) {
+			var watchErr *events.WatchError
+			switch {
+			case errors.As(err, &watchErr):
+				metrics.SchemaReloads.Add("watch_error", 1)
+				log.Printf("Schema watcher on %s failed, changes may not be picked up: %v", dir, err)
+			case err != nil:
+				metrics.SchemaReloads.Add("rejected", 1)
+				log.Printf("Schema reload from %s rejected, keeping the previous schemas: %v", dir, err)
+			default:
+				metrics.SchemaReloads.Add("applied", 1)
+				log.Printf("Schemas reloaded from %s", dir)
+			}
+		})
+		if err != nil {
+			log.Fatalf("failed to watch schema directory: %v", err)
+		}
+	}
+
+	limiter, err := tenantLimiter()
+	if err != nil {
+		log.Fatalf("failed to load tenant rate limits: %v", err)
+	}
+
+	consumers := make([]*sqsconsumer.Consumer, 0, len(queues))
+	for _, queue := range queues {
+		opts := consumerOptions(queue)
+		opts.TenantLimiter = limiter
+		opts.ReceiveLimit = receiveLimit
+		opts.BlobStore = blobStore
+		opts.DeleteBlobs = config.Get().Blob.DeleteOnAck
+		opts.MaxBodySize = config.Get().SQS.MaxBodyBytes
+		opts.MaxBlobSize = config.Get().Blob.MaxBytes
+		consumers = append(consumers, sqsconsumer.NewSqsConsumer(sqsClient, opts))
+	}
+
+	controls := make([]ports.ConsumerControl, 0, len(consumers))
+	for _, c := range consumers {
+		controls = append(controls, c)
+	}
+
+	server := httpapi.NewServer(httpapi.Options{
+		Consumers:  controls,
+		Schemas:    schemaRegistry,
+		AdminToken: config.Get().AdminToken,
+		Ready: func() error {
+			if breaker != nil && breaker.IsOpen() {
+				return errors.New("repository circuit breaker is open")
+			}
+			return nil
+		},
+	})
 	go func() {
-		defer wg.Done()
-		if err := sqsConsumer.Read(ctx, processor.Process); err != nil {
-			log.Fatalf("Consumer stopped with error: %v", err)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", config.Get().Port), server.Handler()); err != nil {
+			log.Printf("HTTP server stopped with error: %v", err)
 		}
 	}()
 
-	fmt.Println("Worker service is running...")
+	if mode := sqsconsumer.SchedulingMode(config.Get().SQS.Scheduling); mode != "" {
+		if mode != sqsconsumer.SchedulingWeighted && mode != sqsconsumer.SchedulingStrict {
+			log.Fatalf("unknown SQS_SCHEDULING mode: %s", mode)
+		}
+
+		scheduled := make([]sqsconsumer.ScheduledQueue, 0, len(queues))
+		for i, queue := range queues {
+			scheduled = append(scheduled, sqsconsumer.ScheduledQueue{Consumer: consumers[i], Weight: queue.Weight})
+		}
+
+		var consumer ports.Consumer = sqsconsumer.NewScheduler(scheduled, sqsconsumer.SchedulerOptions{
+			Mode:         mode,
+			Concurrency:  config.Get().SQS.SchedulerBudget,
+			ReceiveLimit: receiveLimit,
+		})
+
+		fmt.Printf("Worker service is running with %d queue(s) scheduled as %s...\n", len(queues), mode)
+
+		if err := consumer.Read(ctx, process); err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("Scheduler stopped with error: %v", err)
+		}
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, sqsConsumer := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := sqsConsumer.Read(ctx, process)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Consumer for queue %s stopped with error: %v", sqsConsumer.Name(), err)
+				return
+			}
+			log.Printf("Consumer for queue %s stopped", sqsConsumer.Name())
+		}()
+	}
+
+	fmt.Printf("Worker service is running with %d queue(s)...\n", len(queues))
 
 	wg.Wait()
 }
+
+func consumerOptions(queue config.QueueConfig) sqsconsumer.Options {
+	var partitionKey sqsconsumer.KeyExtractor
+	if queue.PartitionKey != "" {
+		partitionKey = sqsconsumer.NewKeyExtractor(strings.Split(queue.PartitionKey, ",")...)
+	}
+
+	return sqsconsumer.Options{
+		Name:         queue.Name,
+		QueueURL:     queue.URL,
+		MaxMessages:  queue.MaxMessages,
+		WaitTimeSec:  queue.WaitTimeSec,
+		MaxRetries:   queue.MaxRetries,
+		FIFO:         queue.FIFO,
+		PartitionKey: partitionKey,
+		Shards:       queue.PartitionShards,
+		Concurrency:  queue.Concurrency,
+	}
+}
+
+// newSchemaRegistry applies the SCHEMA_* settings and loads SCHEMA_DIR.
+func newSchemaRegistry() (*events.SchemaRegistry, error) {
+	schemaCfg := config.Get().Schema
+
+	mode, err := events.ParseCompatibilityMode(schemaCfg.Compatibility)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := events.ParseCompatibilityOverrides(schemaCfg.CompatibilityOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	strictness, err := events.ParseStrictness(schemaCfg.Strictness)
+	if err != nil {
+		return nil, err
+	}
+	strictnessOverrides, err := events.ParseStrictnessOverrides(schemaCfg.StrictnessOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := events.NewSchemaRegistry()
+	registry.SetCompatibility("", mode)
+	for eventType, m := range overrides {
+		registry.SetCompatibility(eventType, m)
+	}
+	registry.SetStrictness("", strictness)
+	for selector, s := range strictnessOverrides {
+		registry.SetStrictness(selector, s)
+	}
+
+	if schemaCfg.Dir != "" {
+		if err := registry.LoadDir(schemaCfg.Dir); err != nil {
+			return nil, fmt.Errorf("failed to load schemas: %w", err)
+		}
+	}
+	return registry, nil
+}
+
+func tenantLimiter() (*ratelimit.Limiter, error) {
+	tenant := config.Get().Tenant
+
+	overrides, err := ratelimit.ParseOverrides(tenant.RateLimitOverrides)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.RateLimit <= 0 && len(overrides) == 0 {
+		return nil, nil
+	}
+
+	return ratelimit.NewLimiter(ratelimit.Limit{Rate: tenant.RateLimit, Burst: tenant.RateBurst}, overrides), nil
+}
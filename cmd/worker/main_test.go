@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSchemaRegistry_LoadsSchemaDir(t *testing.T) {
+	t.Cleanup(func() { _ = config.Load() })
+	t.Setenv("SCHEMA_DIR", "../../schemas")
+	assert.NoError(t, config.Load())
+
+	registry, err := newSchemaRegistry()
+	assert.NoError(t, err)
+
+	_, err = registry.Shape("invoice.issued", "v1")
+	assert.NoError(t, err)
+}
+
+func TestNewSchemaRegistry_FailsOnMissingSchemaDir(t *testing.T) {
+	t.Cleanup(func() { _ = config.Load() })
+	t.Setenv("SCHEMA_DIR", t.TempDir()+"/missing")
+	assert.NoError(t, config.Load())
+
+	_, err := newSchemaRegistry()
+	assert.Error(t, err)
+}
@@ -9,14 +9,20 @@ provider "aws" {
   endpoints {
     dynamodb = "http://localhost:4566"
     sqs      = "http://localhost:4566"
+    s3       = "http://localhost:4566"
   }
+
+  s3_use_path_style = true
 }
 
 locals {
   main_queue_name   = "events-main"
   dlq_queue_name    = "events-dlq"
+  fifo_queue_name   = "events-main.fifo"
+  fifo_dlq_name     = "events-dlq.fifo"
   max_receive_count = 5
   events_table_name = "events"
+  payloads_bucket   = "event-payloads"
 }
 
 resource "aws_sqs_queue" "dlq" {
@@ -35,6 +41,28 @@ resource "aws_sqs_queue" "main" {
   receive_wait_time_seconds = 20
 }
 
+resource "aws_sqs_queue" "fifo_dlq" {
+  name                      = local.fifo_dlq_name
+  fifo_queue                = true
+  message_retention_seconds = 1209600
+}
+
+resource "aws_sqs_queue" "fifo" {
+  name       = local.fifo_queue_name
+  fifo_queue = true
+
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.fifo_dlq.arn
+    maxReceiveCount     = local.max_receive_count
+  })
+
+  receive_wait_time_seconds = 20
+}
+
+resource "aws_s3_bucket" "payloads" {
+  bucket = local.payloads_bucket
+}
+
 resource "aws_dynamodb_table" "events" {
   name         = local.events_table_name
   billing_mode = "PAY_PER_REQUEST"
@@ -64,12 +92,22 @@ resource "aws_dynamodb_table" "events" {
     name = "occurred_at"
     type = "S"
   }
+
+  global_secondary_index {
+    name            = "event_id-index"
+    hash_key        = "event_id"
+    projection_type = "ALL"
+  }
 }
 
 output "main_queue_url" {
   value = aws_sqs_queue.main.url
 }
 
+output "fifo_queue_url" {
+  value = aws_sqs_queue.fifo.url
+}
+
 output "dlq_queue_url" {
   value = aws_sqs_queue.dlq.url
 }
@@ -85,3 +123,7 @@ output "events_table_name" {
 output "events_table_arn" {
   value = aws_dynamodb_table.events.arn
 }
+
+output "payloads_bucket_name" {
+  value = aws_s3_bucket.payloads.bucket
+}
@@ -2,10 +2,16 @@ package dynamodb
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
 	"github.com/guilherme-daniel-rs/event-processor/internal/domain/models"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
 )
 
 type EventRepository struct {
@@ -28,6 +34,57 @@ func (r *EventRepository) Save(ctx context.Context, event models.EventRecord) er
 		TableName: event.TableName(),
 		Item:      item,
 	})
+	if isThrottling(err) {
+		return fmt.Errorf("%w: %w", ports.ErrThrottled, err)
+	}
 
 	return err
 }
+
+// EventIDIndex is the global secondary index on event_id.
+const EventIDIndex = "event_id-index"
+
+// FindByEventID returns every record stored for eventID. More than one means
+// the event was processed more than once.
+func (r *EventRepository) FindByEventID(ctx context.Context, eventID string) ([]models.EventRecord, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              models.EventRecord{}.TableName(),
+		IndexName:              aws.String(EventIDIndex),
+		KeyConditionExpression: aws.String("event_id = :event_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":event_id": &types.AttributeValueMemberS{Value: eventID},
+		},
+	}
+
+	var records []models.EventRecord
+	for {
+		out, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []models.EventRecord
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return records, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+func isThrottling(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		return true
+	}
+	return false
+}
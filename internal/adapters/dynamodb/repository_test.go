@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/guilherme-daniel-rs/event-processor/internal/domain/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,6 +24,14 @@ func (m *MockDynamoDBClient) PutItem(ctx context.Context, params *dynamodb.PutIt
 	return args.Get(0).(*dynamodb.PutItemOutput), args.Error(1)
 }
 
+func (m *MockDynamoDBClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.QueryOutput), args.Error(1)
+}
+
 func TestEventRepository_Save(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockClient := new(MockDynamoDBClient)
@@ -62,3 +71,50 @@ func TestEventRepository_Save(t *testing.T) {
 		mockClient.AssertExpectations(t)
 	})
 }
+
+func TestEventRepository_FindByEventID(t *testing.T) {
+	t.Run("follows pages", func(t *testing.T) {
+		mockClient := new(MockDynamoDBClient)
+		repo := NewEventRepository(mockClient)
+
+		item := func(id string) map[string]types.AttributeValue {
+			return map[string]types.AttributeValue{
+				"id":       &types.AttributeValueMemberS{Value: id},
+				"event_id": &types.AttributeValueMemberS{Value: "evt-1"},
+				"status":   &types.AttributeValueMemberS{Value: "processed"},
+			}
+		}
+		lastKey := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}}
+
+		mockClient.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return *input.IndexName == EventIDIndex && input.ExclusiveStartKey == nil
+		}), mock.Anything).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{item("a")},
+			LastEvaluatedKey: lastKey,
+		}, nil).Once()
+		mockClient.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey != nil
+		}), mock.Anything).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{item("b")},
+		}, nil).Once()
+
+		records, err := repo.FindByEventID(context.Background(), "evt-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []models.EventRecord{
+			{ID: "a", EventID: "evt-1", Status: "processed"},
+			{ID: "b", EventID: "evt-1", Status: "processed"},
+		}, records)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockClient := new(MockDynamoDBClient)
+		repo := NewEventRepository(mockClient)
+
+		mockClient.On("Query", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, errors.New("dynamodb error"))
+
+		_, err := repo.FindByEventID(context.Background(), "evt-1")
+		assert.EqualError(t, err, "dynamodb error")
+	})
+}
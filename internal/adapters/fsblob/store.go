@@ -0,0 +1,89 @@
+package fsblob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+// BlobStore keeps each blob in a file at <root>/<bucket>/<key>. It is meant
+// for tests and local runs without S3.
+type BlobStore struct {
+	root string
+}
+
+func NewBlobStore(root string) *BlobStore {
+	return &BlobStore{
+		root: root,
+	}
+}
+
+func (s *BlobStore) Get(ctx context.Context, bucket, key string, maxSize int64) ([]byte, error) {
+	path, err := s.path(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s/%s", ports.ErrBlobNotFound, bucket, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if maxSize <= 0 {
+		return io.ReadAll(f)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: %s/%s is over %d bytes", ports.ErrBlobTooLarge, bucket, key, maxSize)
+	}
+	return data, nil
+}
+
+func (s *BlobStore) Put(ctx context.Context, bucket, key string, data []byte) error {
+	path, err := s.path(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (s *BlobStore) Delete(ctx context.Context, bucket, key string) error {
+	path, err := s.path(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *BlobStore) path(bucket, key string) (string, error) {
+	if bucket == "" || strings.ContainsAny(bucket, `/\`) || bucket == "." || bucket == ".." {
+		return "", fmt.Errorf("invalid bucket name: %q", bucket)
+	}
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return filepath.Join(s.root, bucket, filepath.FromSlash(key)), nil
+}
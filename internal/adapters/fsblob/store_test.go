@@ -0,0 +1,42 @@
+package fsblob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewBlobStore(t.TempDir())
+
+	assert.NoError(t, store.Put(ctx, "payloads", "a/b", []byte("hello")))
+
+	data, err := store.Get(ctx, "payloads", "a/b", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+
+	data, err = store.Get(ctx, "payloads", "a/b", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+
+	_, err = store.Get(ctx, "payloads", "a/b", 4)
+	assert.ErrorIs(t, err, ports.ErrBlobTooLarge)
+
+	assert.NoError(t, store.Delete(ctx, "payloads", "a/b"))
+	assert.NoError(t, store.Delete(ctx, "payloads", "a/b"))
+
+	_, err = store.Get(ctx, "payloads", "a/b", 0)
+	assert.ErrorIs(t, err, ports.ErrBlobNotFound)
+}
+
+func TestBlobStore_RejectsEscapingPaths(t *testing.T) {
+	ctx := context.Background()
+	store := NewBlobStore(t.TempDir())
+
+	assert.Error(t, store.Put(ctx, "..", "key", nil))
+	assert.Error(t, store.Put(ctx, "payloads", "../key", nil))
+	assert.Error(t, store.Put(ctx, "payloads", "/etc/passwd", nil))
+}
@@ -0,0 +1,139 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type SchemaLister interface {
+	Schemas() []events.SchemaInfo
+}
+
+type Options struct {
+	Consumers  []ports.ConsumerControl
+	Schemas    SchemaLister
+	AdminToken string
+	Ready      func() error
+}
+
+type Server struct {
+	consumers  []ports.ConsumerControl
+	schemas    SchemaLister
+	adminToken string
+	ready      func() error
+}
+
+func NewServer(opts Options) *Server {
+	return &Server{
+		consumers:  opts.Consumers,
+		schemas:    opts.Schemas,
+		adminToken: opts.AdminToken,
+		ready:      opts.Ready,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /readyz", s.readyz)
+
+	mux.Handle("GET /admin/status", s.admin(s.status))
+	mux.Handle("GET /admin/schemas", s.admin(s.listSchemas))
+	mux.Handle("POST /admin/pause", s.admin(s.control(func(c ports.ConsumerControl) error { return c.Pause() })))
+	mux.Handle("POST /admin/resume", s.admin(s.control(func(c ports.ConsumerControl) error { return c.Resume() })))
+	mux.Handle("POST /admin/drain", s.admin(s.control(func(c ports.ConsumerControl) error {
+		c.Drain()
+		return nil
+	})))
+
+	return mux
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.ready != nil {
+		if err := s.ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// admin rejects every request when no token is configured, so the endpoints
+// are never exposed unprotected.
+func (s *Server) admin(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.adminToken == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]ports.ConsumerStatus, 0, len(s.consumers))
+	for _, c := range s.consumers {
+		statuses = append(statuses, c.Status())
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"consumers": statuses})
+}
+
+func (s *Server) listSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas := []events.SchemaInfo{}
+	if s.schemas != nil {
+		schemas = s.schemas.Schemas()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"schemas": schemas})
+}
+
+// control applies action to the consumer named by the queue query parameter,
+// or to every consumer when it is omitted.
+func (s *Server) control(action func(c ports.ConsumerControl) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		targets, err := s.targets(r.URL.Query().Get("queue"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		statuses := make([]ports.ConsumerStatus, 0, len(targets))
+		for _, c := range targets {
+			if err := action(c); err != nil {
+				http.Error(w, fmt.Sprintf("queue %s: %v", c.Name(), err), http.StatusConflict)
+				return
+			}
+			statuses = append(statuses, c.Status())
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"consumers": statuses})
+	}
+}
+
+func (s *Server) targets(queue string) ([]ports.ConsumerControl, error) {
+	if queue == "" {
+		return s.consumers, nil
+	}
+	for _, c := range s.consumers {
+		if c.Name() == queue {
+			return []ports.ConsumerControl{c}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown queue: %s", queue)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
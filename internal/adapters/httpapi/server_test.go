@@ -0,0 +1,143 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConsumer struct {
+	name  string
+	state ports.RunState
+}
+
+func (f *fakeConsumer) Name() string { return f.name }
+
+func (f *fakeConsumer) Pause() error {
+	if f.state != ports.RunStateRunning {
+		return errors.New("not running")
+	}
+	f.state = ports.RunStatePaused
+	return nil
+}
+
+func (f *fakeConsumer) Resume() error {
+	f.state = ports.RunStateRunning
+	return nil
+}
+
+func (f *fakeConsumer) Drain() { f.state = ports.RunStateDraining }
+
+func (f *fakeConsumer) Status() ports.ConsumerStatus {
+	return ports.ConsumerStatus{
+		Name:     f.name,
+		State:    f.state,
+		InFlight: []ports.InFlightMessage{{ID: "msg-1", Attempt: 2, AgeSeconds: 1.5}},
+	}
+}
+
+func newTestServer(ready func() error) (http.Handler, *fakeConsumer, *fakeConsumer) {
+	critical := &fakeConsumer{name: "critical", state: ports.RunStateRunning}
+	bulk := &fakeConsumer{name: "bulk", state: ports.RunStateRunning}
+
+	server := NewServer(Options{
+		Consumers:  []ports.ConsumerControl{critical, bulk},
+		Schemas:    events.NewSchemaRegistry(),
+		AdminToken: "secret",
+		Ready:      ready,
+	})
+	return server.Handler(), critical, bulk
+}
+
+func serve(handler http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_Admin(t *testing.T) {
+	t.Run("rejects missing or wrong token", func(t *testing.T) {
+		handler, _, _ := newTestServer(nil)
+		assert.Equal(t, http.StatusUnauthorized, serve(handler, http.MethodGet, "/admin/status", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(handler, http.MethodGet, "/admin/status", "wrong").Code)
+	})
+
+	t.Run("rejects everything when no token is configured", func(t *testing.T) {
+		handler := NewServer(Options{}).Handler()
+		assert.Equal(t, http.StatusUnauthorized, serve(handler, http.MethodGet, "/admin/status", "").Code)
+	})
+
+	t.Run("reports status with in flight messages", func(t *testing.T) {
+		handler, _, _ := newTestServer(nil)
+		rec := serve(handler, http.MethodGet, "/admin/status", "secret")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Consumers []ports.ConsumerStatus `json:"consumers"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Len(t, body.Consumers, 2)
+		assert.Equal(t, "msg-1", body.Consumers[0].InFlight[0].ID)
+		assert.Equal(t, 2, body.Consumers[0].InFlight[0].Attempt)
+	})
+
+	t.Run("pauses and resumes a single queue", func(t *testing.T) {
+		handler, critical, bulk := newTestServer(nil)
+
+		assert.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/admin/pause?queue=bulk", "secret").Code)
+		assert.Equal(t, ports.RunStateRunning, critical.state)
+		assert.Equal(t, ports.RunStatePaused, bulk.state)
+
+		assert.Equal(t, http.StatusConflict, serve(handler, http.MethodPost, "/admin/pause?queue=bulk", "secret").Code)
+
+		assert.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/admin/resume?queue=bulk", "secret").Code)
+		assert.Equal(t, ports.RunStateRunning, bulk.state)
+	})
+
+	t.Run("drains every queue", func(t *testing.T) {
+		handler, critical, bulk := newTestServer(nil)
+
+		assert.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/admin/drain", "secret").Code)
+		assert.Equal(t, ports.RunStateDraining, critical.state)
+		assert.Equal(t, ports.RunStateDraining, bulk.state)
+	})
+
+	t.Run("lists loaded schemas", func(t *testing.T) {
+		handler, _, _ := newTestServer(nil)
+		rec := serve(handler, http.MethodGet, "/admin/schemas", "secret")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Schemas []events.SchemaInfo `json:"schemas"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Len(t, body.Schemas, 3)
+		assert.Equal(t, "order.placed", body.Schemas[0].EventType)
+	})
+
+	t.Run("unknown queue", func(t *testing.T) {
+		handler, _, _ := newTestServer(nil)
+		assert.Equal(t, http.StatusNotFound, serve(handler, http.MethodPost, "/admin/pause?queue=missing", "secret").Code)
+	})
+}
+
+func TestServer_Readyz(t *testing.T) {
+	handler, _, _ := newTestServer(nil)
+	assert.Equal(t, http.StatusOK, serve(handler, http.MethodGet, "/readyz", "").Code)
+	assert.Equal(t, http.StatusOK, serve(handler, http.MethodGet, "/healthz", "").Code)
+
+	handler, _, _ = newTestServer(func() error { return errors.New("repository circuit breaker is open") })
+	rec := serve(handler, http.MethodGet, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "circuit breaker is open")
+}
@@ -0,0 +1,73 @@
+package s3blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type BlobStore struct {
+	client S3Client
+}
+
+func NewBlobStore(client S3Client) *BlobStore {
+	return &BlobStore{
+		client: client,
+	}
+}
+
+func (s *BlobStore) Get(ctx context.Context, bucket, key string, maxSize int64) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%w: %w", ports.ErrBlobNotFound, err)
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	if maxSize <= 0 {
+		return io.ReadAll(out.Body)
+	}
+	if out.ContentLength != nil && *out.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: s3://%s/%s is %d bytes", ports.ErrBlobTooLarge, bucket, key, *out.ContentLength)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: s3://%s/%s is over %d bytes", ports.ErrBlobTooLarge, bucket, key, maxSize)
+	}
+	return data, nil
+}
+
+func (s *BlobStore) Put(ctx context.Context, bucket, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	return err
+}
+
+func (s *BlobStore) Delete(ctx context.Context, bucket, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
@@ -2,54 +2,157 @@ package sqsconsumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"maps"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/claimcheck"
+	"github.com/guilherme-daniel-rs/event-processor/internal/contentencoding"
 	"github.com/guilherme-daniel-rs/event-processor/internal/logging"
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
 	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ratelimit"
 )
 
 type Consumer struct {
-	client      SQSClient
-	queueURL    string
-	maxMessages int32
-	waitTimeSec int32
-	maxRetries  int32
+	client       SQSClient
+	name         string
+	queueURL     string
+	maxMessages  int32
+	waitTimeSec  int32
+	maxRetries   int32
+	fifo         bool
+	partitionKey KeyExtractor
+	shards       int
+	slots        chan struct{}
+	limiter      *ratelimit.Limiter
+	tenantKey    KeyExtractor
+	receiveLimit func() int
+	blobs        ports.BlobStore
+	deleteBlobs  bool
+	maxBodySize  int64
+	maxBlobSize  int64
+	control      *runControl
+
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
 type Options struct {
-	QueueURL    string
-	MaxMessages int32
-	WaitTimeSec int32
-	MaxRetries  int32
+	Name          string
+	QueueURL      string
+	MaxMessages   int32
+	WaitTimeSec   int32
+	MaxRetries    int32
+	FIFO          bool
+	PartitionKey  KeyExtractor
+	Shards        int
+	Concurrency   int
+	TenantLimiter *ratelimit.Limiter
+	TenantKey     KeyExtractor
+	// ReceiveLimit caps how many messages a poll may receive, such as the
+	// probes a half-open circuit breaker has left. Zero pauses polling and a
+	// negative value means no cap.
+	ReceiveLimit func() int
+	BlobStore    ports.BlobStore
+	DeleteBlobs  bool
+	MaxBodySize  int64
+	// MaxBlobSize caps the claim-check payloads fetched from BlobStore,
+	// contentencoding.DefaultMaxSize when zero.
+	MaxBlobSize int64
 }
 
 type nackOptions struct {
 	DelayBeforeRetrySeconds int32
 }
 
+const (
+	pausePollInterval       = time.Second
+	circuitOpenDelaySeconds = int32(10)
+)
+
 func NewSqsConsumer(client SQSClient, opts Options) *Consumer {
+	shards := opts.Shards
+	if shards <= 0 {
+		shards = defaultShards
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = opts.QueueURL
+	}
+
+	var slots chan struct{}
+	if opts.Concurrency > 0 {
+		slots = make(chan struct{}, opts.Concurrency)
+	}
+
+	tenantKey := opts.TenantKey
+	if tenantKey == nil {
+		tenantKey = NewKeyExtractor("tenant_id")
+	}
+
+	maxBlobSize := opts.MaxBlobSize
+	if maxBlobSize <= 0 {
+		maxBlobSize = contentencoding.DefaultMaxSize
+	}
+
 	return &Consumer{
-		client:      client,
-		queueURL:    opts.QueueURL,
-		maxMessages: opts.MaxMessages,
-		waitTimeSec: opts.WaitTimeSec,
-		maxRetries:  opts.MaxRetries,
+		client:       client,
+		name:         name,
+		queueURL:     opts.QueueURL,
+		maxMessages:  opts.MaxMessages,
+		waitTimeSec:  opts.WaitTimeSec,
+		maxRetries:   opts.MaxRetries,
+		fifo:         opts.FIFO,
+		partitionKey: opts.PartitionKey,
+		shards:       shards,
+		slots:        slots,
+		limiter:      opts.TenantLimiter,
+		tenantKey:    tenantKey,
+		receiveLimit: opts.ReceiveLimit,
+		blobs:        opts.BlobStore,
+		deleteBlobs:  opts.DeleteBlobs,
+		maxBodySize:  opts.MaxBodySize,
+		maxBlobSize:  maxBlobSize,
+		control:      newRunControl(),
+		sleep:        sleepContext,
 	}
 }
 
+func (c *Consumer) Name() string {
+	return c.name
+}
+
 func (c *Consumer) Receive(ctx context.Context) ([]ports.Message, error) {
+	return c.receive(ctx, c.maxMessages, c.waitTimeSec)
+}
+
+// limit returns how many messages the next poll may receive, up to max.
+func (c *Consumer) limit(max int32) int32 {
+	if c.receiveLimit == nil {
+		return max
+	}
+	if n := c.receiveLimit(); n >= 0 {
+		return min(max, int32(n))
+	}
+	return max
+}
+
+func (c *Consumer) receive(ctx context.Context, maxMessages, waitTimeSec int32) ([]ports.Message, error) {
 	out, err := c.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
 		QueueUrl:            aws.String(c.queueURL),
-		MaxNumberOfMessages: c.maxMessages,
-		WaitTimeSeconds:     c.waitTimeSec,
+		MaxNumberOfMessages: maxMessages,
+		WaitTimeSeconds:     waitTimeSec,
 		AttributeNames: []types.QueueAttributeName{
 			types.QueueAttributeNameAll,
 		},
+		MessageAttributeNames: []string{"All"},
 	})
 	if err != nil {
 		return nil, err
@@ -91,54 +194,235 @@ func (c *Consumer) Read(ctx context.Context, process func(ctx context.Context, m
 		default:
 		}
 
-		messages, err := c.Receive(ctx)
-		if err != nil {
+		switch c.runState() {
+		case ports.RunStateDraining, ports.RunStateStopped:
+			c.stop()
+			return nil
+		case ports.RunStatePaused:
+			if err := c.sleep(ctx, pausePollInterval); err != nil {
+				return err
+			}
 			continue
 		}
 
-		var wg sync.WaitGroup
-		for _, msg := range messages {
-			if ctx.Err() != nil {
-				return ctx.Err()
+		maxMessages := c.limit(batchSize(c))
+		if maxMessages == 0 {
+			if err := c.sleep(ctx, pausePollInterval); err != nil {
+				return err
 			}
+			continue
+		}
+
+		messages, err := c.receive(ctx, maxMessages, c.waitTimeSec)
+		if err != nil {
+			metrics.ReceiveErrors.Add(c.name, 1)
+			continue
+		}
+		metrics.MessagesReceived.Add(c.name, int64(len(messages)))
+
+		if err := c.processBatch(ctx, messages, process); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Consumer) processBatch(ctx context.Context, messages []ports.Message, process func(ctx context.Context, msg ports.Message) error) error {
+	batch := make([]received, 0, len(messages))
+	for _, m := range messages {
+		batch = append(batch, c.prepare(ctx, m))
+	}
+
+	if c.fifo {
+		return c.processGroups(ctx, batch, process)
+	}
+
+	if c.partitionKey != nil {
+		return c.processPartitions(ctx, batch, process)
+	}
+
+	if c.limiter != nil {
+		batch = c.interleaveTenants(batch)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, r := range batch {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.throttle(ctx, r) != nil {
+			continue
+		}
+		if err := c.acquire(ctx); err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func(r received) {
+			defer wg.Done()
+			defer c.releaseSlot()
+			c.handle(ctx, r, process)
+		}(r)
+	}
+
+	return nil
+}
+
+func (c *Consumer) acquire(ctx context.Context) error {
+	if c.slots == nil {
+		return nil
+	}
 
-			wg.Add(1)
-			go func(m ports.Message) {
-				defer wg.Done()
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-				tCtx := logging.WithTrace(ctx, m.ID)
-				logging.Append(tCtx, "Started processing message (attempt %d)", m.ReceiveCount)
+func (c *Consumer) releaseSlot() {
+	if c.slots != nil {
+		<-c.slots
+	}
+}
 
-				err := process(tCtx, m)
+// handleOrdered is handle for the FIFO and partitioned paths, where a
+// throttled message counts as a failure so the messages after it wait.
+func (c *Consumer) handleOrdered(ctx context.Context, r received, process func(ctx context.Context, msg ports.Message) error) error {
+	if throttled := c.throttle(ctx, r); throttled != nil {
+		return throttled
+	}
+	if err := c.acquire(ctx); err != nil {
+		return err
+	}
+	defer c.releaseSlot()
+
+	return c.handle(ctx, r, process)
+}
+
+func (c *Consumer) handle(ctx context.Context, r received, process func(ctx context.Context, msg ports.Message) error) error {
+	m := r.Message
 
-				logging.Flush(tCtx, err)
+	metrics.MessagesInFlight.Add(c.name, 1)
+	defer metrics.MessagesInFlight.Add(c.name, -1)
+	defer c.track(m)()
 
-				if err == nil {
-					_ = c.Ack(ctx, m)
-					return
-				}
+	tCtx := logging.WithTrace(ctx, m.ID)
+	logging.Append(tCtx, "Started processing message (attempt %d) from queue %s", m.ReceiveCount, c.name)
 
-				if ports.IsNonRetriable(err) {
-					_ = c.Ack(ctx, m)
-					return
-				}
+	err := r.err
+	if err == nil {
+		err = process(tCtx, m)
+	}
 
-				if c.maxRetries > 0 && int32(m.ReceiveCount) >= c.maxRetries {
-					_ = c.Nack(ctx, m, nackOptions{
-						DelayBeforeRetrySeconds: 0,
-					})
-					return
-				}
+	logging.Flush(tCtx, err)
 
-				delay := calculateBackoffDelay(int32(m.ReceiveCount))
+	if err == nil {
+		metrics.MessagesAcked.Add(c.name, 1)
+		if c.Ack(ctx, m) == nil {
+			c.deleteBlob(ctx, r.pointer)
+		}
+		return nil
+	}
 
-				_ = c.Nack(ctx, m, nackOptions{
-					DelayBeforeRetrySeconds: delay,
-				})
-			}(msg)
+	if ports.IsNonRetriable(err) {
+		metrics.MessagesAcked.Add(c.name, 1)
+		if c.Ack(ctx, m) == nil {
+			c.deleteBlob(ctx, r.pointer)
 		}
-		wg.Wait()
+		return nil
+	}
+
+	if errors.Is(err, ports.ErrCircuitOpen) {
+		metrics.MessagesCircuitOpen.Add(c.name, 1)
+		_ = c.Nack(ctx, m, nackOptions{
+			DelayBeforeRetrySeconds: circuitOpenDelaySeconds,
+		})
+		return err
+	}
+
+	if c.maxRetries > 0 && int32(m.ReceiveCount) >= c.maxRetries {
+		metrics.MessagesDeadLetter.Add(c.name, 1)
+		_ = c.Nack(ctx, m, nackOptions{
+			DelayBeforeRetrySeconds: 0,
+		})
+		return err
+	}
+
+	metrics.MessagesRetried.Add(c.name, 1)
+	delay := calculateBackoffDelay(int32(m.ReceiveCount))
+
+	_ = c.Nack(ctx, m, nackOptions{
+		DelayBeforeRetrySeconds: delay,
+	})
+	return err
+}
+
+// received is a message as it is dispatched: with its claim-check payload
+// resolved and its body decoded, or with the error that prevented it.
+type received struct {
+	ports.Message
+	pointer *claimcheck.Pointer
+	err     error
+}
+
+// prepare resolves and decodes a message before it is grouped, partitioned
+// or rate limited, so those keys are read from the real envelope rather than
+// from a claim-check pointer or a compressed body. A message that cannot be
+// prepared is still dispatched, and handle settles it with the error.
+func (c *Consumer) prepare(ctx context.Context, m ports.Message) received {
+	pointer, err := c.resolvePayload(ctx, &m)
+	if err == nil {
+		err = c.decodeBody(&m)
+	}
+	return received{Message: m, pointer: pointer, err: err}
+}
+
+// resolvePayload replaces a claim-check pointer body with the payload it
+// refers to. A payload that no longer exists or is over maxBlobSize will
+// never resolve, so it is reported as non-retriable.
+func (c *Consumer) resolvePayload(ctx context.Context, m *ports.Message) (*claimcheck.Pointer, error) {
+	if c.blobs == nil {
+		return nil, nil
+	}
+
+	body, pointer, err := claimcheck.Resolve(ctx, c.blobs, m.Body, c.maxBlobSize)
+	if errors.Is(err, ports.ErrBlobNotFound) || errors.Is(err, ports.ErrBlobTooLarge) {
+		return nil, ports.NewNonRetriableError(err)
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	m.Body = body
+	return pointer, nil
+}
+
+// decodeBody undoes the compression and base64 encoding named by the
+// content-encoding message attribute, or detected from the body itself.
+func (c *Consumer) decodeBody(m *ports.Message) error {
+	encoding := m.MessageAttributes["content-encoding"]
+	if encoding == "" {
+		encoding = m.MessageAttributes["Content-Encoding"]
+	}
+
+	body, err := contentencoding.Decode(m.Body, encoding, c.maxBodySize)
+	if err != nil {
+		return ports.NewNonRetriableError(fmt.Errorf("failed to decode message body: %w", err))
+	}
+
+	m.Body = body
+	return nil
+}
+
+func (c *Consumer) deleteBlob(ctx context.Context, pointer *claimcheck.Pointer) {
+	if pointer == nil || !c.deleteBlobs {
+		return
+	}
+	if err := c.blobs.Delete(ctx, pointer.Bucket, pointer.Key); err != nil {
+		metrics.BlobDeleteErrors.Add(c.name, 1)
 	}
 }
 
@@ -173,6 +457,13 @@ func toPortsMessage(m types.Message) ports.Message {
 		}
 	}
 
+	messageAttrs := map[string]string{}
+	for name, value := range m.MessageAttributes {
+		if value.StringValue != nil {
+			messageAttrs[name] = *value.StringValue
+		}
+	}
+
 	id := ""
 	if m.MessageId != nil {
 		id = *m.MessageId
@@ -189,10 +480,11 @@ func toPortsMessage(m types.Message) ports.Message {
 	}
 
 	return ports.Message{
-		ID:           id,
-		Body:         body,
-		Attributes:   attrs,
-		AckToken:     ackToken,
-		ReceiveCount: receiveCount,
+		ID:                id,
+		Body:              body,
+		Attributes:        attrs,
+		MessageAttributes: messageAttrs,
+		AckToken:          ackToken,
+		ReceiveCount:      receiveCount,
 	}
 }
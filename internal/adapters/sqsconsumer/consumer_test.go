@@ -1,14 +1,22 @@
 package sqsconsumer
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/fsblob"
+	"github.com/guilherme-daniel-rs/event-processor/internal/circuitbreaker"
+	"github.com/guilherme-daniel-rs/event-processor/internal/claimcheck"
 	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -138,3 +146,381 @@ func TestConsumer_Nack(t *testing.T) {
 	err := consumer.Nack(context.Background(), msg, nackOptions{DelayBeforeRetrySeconds: 30})
 	assert.NoError(t, err)
 }
+
+func fifoMessage(id, group, sequence string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("handle-" + id),
+		Attributes: map[string]string{
+			"ApproximateReceiveCount": "1",
+			"MessageGroupId":          group,
+			"SequenceNumber":          sequence,
+		},
+	}
+}
+
+func TestConsumer_ReadFIFO(t *testing.T) {
+	t.Run("processes each group in sequence order", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{QueueURL: "test-queue.fifo", FIFO: true})
+
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{
+			Messages: []types.Message{
+				fifoMessage("a-2", "group-a", "18870000000000000002"),
+				fifoMessage("b-1", "group-b", "18870000000000000003"),
+				fifoMessage("a-1", "group-a", "18870000000000000001"),
+				fifoMessage("a-3", "group-a", "18870000000000000004"),
+			},
+		}, nil).Once()
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+		mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(4)
+
+		var mu sync.Mutex
+		processed := map[string][]string{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			group := msg.Attributes["MessageGroupId"]
+			processed[group] = append(processed[group], msg.ID)
+			return nil
+		})
+
+		assert.Equal(t, []string{"a-1", "a-2", "a-3"}, processed["group-a"])
+		assert.Equal(t, []string{"b-1"}, processed["group-b"])
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("does not ack messages after a failure in the same group", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{QueueURL: "test-queue.fifo", FIFO: true})
+
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{
+			Messages: []types.Message{
+				fifoMessage("a-1", "group-a", "1"),
+				fifoMessage("a-2", "group-a", "2"),
+				fifoMessage("a-3", "group-a", "3"),
+				fifoMessage("b-1", "group-b", "4"),
+			},
+		}, nil).Once()
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+
+		mockClient.On("DeleteMessage", mock.Anything, mock.MatchedBy(func(input *sqs.DeleteMessageInput) bool {
+			return *input.ReceiptHandle == "handle-a-1" || *input.ReceiptHandle == "handle-b-1"
+		}), mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(2)
+		mockClient.On("ChangeMessageVisibility", mock.Anything, mock.MatchedBy(func(input *sqs.ChangeMessageVisibilityInput) bool {
+			return *input.ReceiptHandle == "handle-a-2" && input.VisibilityTimeout == 30
+		}), mock.Anything).Return(&sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+		mockClient.On("ChangeMessageVisibility", mock.Anything, mock.MatchedBy(func(input *sqs.ChangeMessageVisibilityInput) bool {
+			return *input.ReceiptHandle == "handle-a-3" && input.VisibilityTimeout == 0
+		}), mock.Anything).Return(&sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+
+		var mu sync.Mutex
+		processed := []string{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			processed = append(processed, msg.ID)
+			if msg.ID == "a-2" {
+				return errors.New("db error")
+			}
+			return nil
+		})
+
+		assert.ElementsMatch(t, []string{"a-1", "a-2", "b-1"}, processed)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestConsumer_Concurrency(t *testing.T) {
+	mockClient := new(MockSQSClient)
+	consumer := NewSqsConsumer(mockClient, Options{Name: "bulk", QueueURL: "test-queue", Concurrency: 2})
+
+	sqsMessages := []types.Message{}
+	for i := 0; i < 6; i++ {
+		sqsMessages = append(sqsMessages, types.Message{
+			MessageId:     aws.String(fmt.Sprintf("msg-%d", i)),
+			ReceiptHandle: aws.String(fmt.Sprintf("handle-%d", i)),
+		})
+	}
+
+	mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{Messages: sqsMessages}, nil).Once()
+	mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+	mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(6)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	assert.Equal(t, 2, maxRunning)
+	assert.Equal(t, "bulk", consumer.Name())
+	mockClient.AssertExpectations(t)
+}
+
+func TestConsumer_CircuitOpen(t *testing.T) {
+	t.Run("defers messages without sending them to the dlq", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{QueueURL: "test-queue", MaxRetries: 3})
+
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{
+			Messages: []types.Message{
+				{
+					MessageId:     aws.String("msg-1"),
+					ReceiptHandle: aws.String("handle-1"),
+					Attributes:    map[string]string{"ApproximateReceiveCount": "3"},
+				},
+			},
+		}, nil).Once()
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+		mockClient.On("ChangeMessageVisibility", mock.Anything, mock.MatchedBy(func(input *sqs.ChangeMessageVisibilityInput) bool {
+			return *input.ReceiptHandle == "handle-1" && input.VisibilityTimeout == circuitOpenDelaySeconds
+		}), mock.Anything).Return(&sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			return fmt.Errorf("failed to save event to repository: %w", ports.ErrCircuitOpen)
+		})
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("stops polling while paused", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{
+			QueueURL:     "test-queue",
+			ReceiveLimit: func() int { return 0 },
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := consumer.Read(ctx, noopProcess)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		mockClient.AssertNotCalled(t, "ReceiveMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("receives only the probes the breaker has left across open cycles", func(t *testing.T) {
+		dbErr := errors.New("database unavailable")
+		breaker := circuitbreaker.NewBreaker(circuitbreaker.Options{
+			Name:             t.Name(),
+			FailureThreshold: 1,
+			OpenTimeout:      5 * time.Millisecond,
+			MaxProbes:        1,
+		})
+		_ = breaker.Allow()
+		breaker.Done(dbErr)
+
+		client := &fakeSQSClient{endless: map[string]bool{"test-queue": true}}
+		consumer := NewSqsConsumer(client, Options{
+			QueueURL:     "test-queue",
+			MaxMessages:  10,
+			ReceiveLimit: breaker.Available,
+		})
+		consumer.sleep = func(ctx context.Context, d time.Duration) error {
+			return sleepContext(ctx, time.Millisecond)
+		}
+
+		var mu sync.Mutex
+		probes, deferred := 0, 0
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if err := breaker.Allow(); err != nil {
+				deferred++
+				return err
+			}
+			probes++
+			breaker.Done(dbErr)
+			return dbErr
+		})
+
+		received := 0
+		for _, n := range client.requested {
+			received += int(n)
+		}
+		assert.Zero(t, deferred)
+		assert.GreaterOrEqual(t, probes, 3)
+		assert.Equal(t, probes, received)
+	})
+}
+
+func TestConsumer_ClaimCheck(t *testing.T) {
+	newConsumer := func(mockClient *MockSQSClient, store ports.BlobStore) *Consumer {
+		return NewSqsConsumer(mockClient, Options{
+			QueueURL:    "test-queue",
+			BlobStore:   store,
+			DeleteBlobs: true,
+		})
+	}
+
+	t.Run("resolves the pointer and deletes the blob on ack", func(t *testing.T) {
+		store := fsblob.NewBlobStore(t.TempDir())
+		assert.NoError(t, store.Put(context.Background(), "payloads", "key-1", []byte(`{"event_id":"1"}`)))
+		body, err := claimcheck.Encode(claimcheck.Pointer{Bucket: "payloads", Key: "key-1"})
+		assert.NoError(t, err)
+
+		mockClient := new(MockSQSClient)
+		mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Once()
+
+		var processed []byte
+		consumer := newConsumer(mockClient, store)
+		err = consumer.handle(context.Background(), consumer.prepare(context.Background(), ports.Message{ID: "msg-1", Body: body, AckToken: "handle-1"}), func(ctx context.Context, msg ports.Message) error {
+			processed = msg.Body
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"event_id":"1"}`, string(processed))
+		_, err = store.Get(context.Background(), "payloads", "key-1", 0)
+		assert.ErrorIs(t, err, ports.ErrBlobNotFound)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("acks a pointer to a missing blob without processing", func(t *testing.T) {
+		body, err := claimcheck.Encode(claimcheck.Pointer{Bucket: "payloads", Key: "missing"})
+		assert.NoError(t, err)
+
+		mockClient := new(MockSQSClient)
+		mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Once()
+
+		consumer := newConsumer(mockClient, fsblob.NewBlobStore(t.TempDir()))
+		err = consumer.handle(context.Background(), consumer.prepare(context.Background(), ports.Message{ID: "msg-1", Body: body, AckToken: "handle-1"}), func(ctx context.Context, msg ports.Message) error {
+			t.Fatal("process must not be called")
+			return nil
+		})
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("acks a payload over the size limit without processing", func(t *testing.T) {
+		store := fsblob.NewBlobStore(t.TempDir())
+		assert.NoError(t, store.Put(context.Background(), "payloads", "key-1", []byte(`{"event_id":"1"}`)))
+		body, err := claimcheck.Encode(claimcheck.Pointer{Bucket: "payloads", Key: "key-1"})
+		assert.NoError(t, err)
+
+		mockClient := new(MockSQSClient)
+		mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Once()
+
+		consumer := NewSqsConsumer(mockClient, Options{QueueURL: "test-queue", BlobStore: store, MaxBlobSize: 8})
+		r := consumer.prepare(context.Background(), ports.Message{ID: "msg-1", Body: body, AckToken: "handle-1"})
+		assert.ErrorIs(t, r.err, ports.ErrBlobTooLarge)
+		assert.True(t, ports.IsNonRetriable(r.err))
+
+		err = consumer.handle(context.Background(), r, func(ctx context.Context, msg ports.Message) error {
+			t.Fatal("process must not be called")
+			return nil
+		})
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("keeps the blob when processing fails", func(t *testing.T) {
+		store := fsblob.NewBlobStore(t.TempDir())
+		assert.NoError(t, store.Put(context.Background(), "payloads", "key-1", []byte(`{}`)))
+		body, err := claimcheck.Encode(claimcheck.Pointer{Bucket: "payloads", Key: "key-1"})
+		assert.NoError(t, err)
+
+		mockClient := new(MockSQSClient)
+		mockClient.On("ChangeMessageVisibility", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+
+		consumer := newConsumer(mockClient, store)
+		err = consumer.handle(context.Background(), consumer.prepare(context.Background(), ports.Message{ID: "msg-1", Body: body, AckToken: "handle-1", ReceiveCount: 1}), func(ctx context.Context, msg ports.Message) error {
+			return errors.New("database unavailable")
+		})
+
+		assert.Error(t, err)
+		_, err = store.Get(context.Background(), "payloads", "key-1", 0)
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestConsumer_DecodeBody(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(`{"event_id":"1"}`))
+	_ = w.Close()
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	mockClient := new(MockSQSClient)
+	mockClient.On("ReceiveMessage", mock.Anything, mock.MatchedBy(func(input *sqs.ReceiveMessageInput) bool {
+		return len(input.MessageAttributeNames) == 1 && input.MessageAttributeNames[0] == "All"
+	}), mock.Anything).Return(&sqs.ReceiveMessageOutput{
+		Messages: []types.Message{
+			{
+				MessageId:     aws.String("msg-1"),
+				Body:          aws.String(encoded),
+				ReceiptHandle: aws.String("handle-1"),
+				MessageAttributes: map[string]types.MessageAttributeValue{
+					"content-encoding": {DataType: aws.String("String"), StringValue: aws.String("gzip")},
+				},
+			},
+		},
+	}, nil)
+
+	msgs, err := NewSqsConsumer(mockClient, Options{QueueURL: "test-queue"}).Receive(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+
+	t.Run("decompresses before processing", func(t *testing.T) {
+		client := new(MockSQSClient)
+		client.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Once()
+
+		var processed []byte
+		consumer := NewSqsConsumer(client, Options{QueueURL: "test-queue"})
+		err := consumer.handle(context.Background(), consumer.prepare(context.Background(), msgs[0]), func(ctx context.Context, msg ports.Message) error {
+			processed = msg.Body
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"event_id":"1"}`, string(processed))
+		client.AssertExpectations(t)
+	})
+
+	t.Run("acks bodies over the size limit without processing", func(t *testing.T) {
+		client := new(MockSQSClient)
+		client.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Once()
+
+		consumer := NewSqsConsumer(client, Options{QueueURL: "test-queue", MaxBodySize: 4})
+		err := consumer.handle(context.Background(), consumer.prepare(context.Background(), msgs[0]), func(ctx context.Context, msg ports.Message) error {
+			t.Fatal("process must not be called")
+			return nil
+		})
+
+		assert.NoError(t, err)
+		client.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,112 @@
+package sqsconsumer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type runControl struct {
+	mu       sync.Mutex
+	state    ports.RunState
+	inFlight map[string]inFlight
+}
+
+type inFlight struct {
+	attempt   int
+	startedAt time.Time
+}
+
+func newRunControl() *runControl {
+	return &runControl{
+		state:    ports.RunStateRunning,
+		inFlight: make(map[string]inFlight),
+	}
+}
+
+func (c *Consumer) Pause() error {
+	return c.control.transition(ports.RunStatePaused, ports.RunStateRunning, ports.RunStatePaused)
+}
+
+func (c *Consumer) Resume() error {
+	return c.control.transition(ports.RunStateRunning, ports.RunStateRunning, ports.RunStatePaused)
+}
+
+// Drain stops receiving new messages. Read returns once the messages already
+// received have been settled.
+func (c *Consumer) Drain() {
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+
+	if c.control.state != ports.RunStateStopped {
+		c.control.state = ports.RunStateDraining
+	}
+}
+
+func (c *Consumer) Status() ports.ConsumerStatus {
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+
+	now := time.Now()
+	messages := make([]ports.InFlightMessage, 0, len(c.control.inFlight))
+	for id, m := range c.control.inFlight {
+		messages = append(messages, ports.InFlightMessage{
+			ID:         id,
+			Attempt:    m.attempt,
+			AgeSeconds: now.Sub(m.startedAt).Seconds(),
+		})
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].AgeSeconds > messages[j].AgeSeconds
+	})
+
+	return ports.ConsumerStatus{
+		Name:     c.name,
+		State:    c.control.state,
+		InFlight: messages,
+	}
+}
+
+func (c *Consumer) runState() ports.RunState {
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+
+	return c.control.state
+}
+
+func (c *Consumer) stop() {
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+
+	c.control.state = ports.RunStateStopped
+}
+
+func (c *Consumer) track(m ports.Message) func() {
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+
+	c.control.inFlight[m.ID] = inFlight{attempt: m.ReceiveCount, startedAt: time.Now()}
+
+	return func() {
+		c.control.mu.Lock()
+		defer c.control.mu.Unlock()
+
+		delete(c.control.inFlight, m.ID)
+	}
+}
+
+func (rc *runControl) transition(to ports.RunState, from ...ports.RunState) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, state := range from {
+		if rc.state == state {
+			rc.state = to
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot move from %s to %s", rc.state, to)
+}
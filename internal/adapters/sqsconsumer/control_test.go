@@ -0,0 +1,90 @@
+package sqsconsumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConsumer_RunControl(t *testing.T) {
+	t.Run("pause and resume", func(t *testing.T) {
+		consumer := NewSqsConsumer(new(MockSQSClient), Options{Name: "main"})
+
+		assert.NoError(t, consumer.Pause())
+		assert.Equal(t, ports.RunStatePaused, consumer.Status().State)
+		assert.NoError(t, consumer.Pause())
+
+		assert.NoError(t, consumer.Resume())
+		assert.Equal(t, ports.RunStateRunning, consumer.Status().State)
+
+		consumer.Drain()
+		assert.Error(t, consumer.Pause())
+	})
+
+	t.Run("paused consumer does not poll", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{QueueURL: "test-queue"})
+		assert.NoError(t, consumer.Pause())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, consumer.Read(ctx, noopProcess), context.DeadlineExceeded)
+		mockClient.AssertNotCalled(t, "ReceiveMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("drain settles in flight messages then stops", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{Name: "main", QueueURL: "test-queue"})
+
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{
+			Messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("handle-1"),
+				Attributes:    map[string]string{"ApproximateReceiveCount": "2"},
+			}},
+		}, nil).Once()
+		mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Once()
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		done := make(chan error)
+
+		go func() {
+			done <- consumer.Read(context.Background(), func(ctx context.Context, msg ports.Message) error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+
+		<-started
+		status := consumer.Status()
+		assert.Len(t, status.InFlight, 1)
+		assert.Equal(t, "msg-1", status.InFlight[0].ID)
+		assert.Equal(t, 2, status.InFlight[0].Attempt)
+
+		consumer.Drain()
+		assert.Equal(t, ports.RunStateDraining, consumer.Status().State)
+		close(finish)
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("consumer did not stop after draining")
+		}
+
+		assert.Equal(t, ports.RunStateStopped, consumer.Status().State)
+		assert.Empty(t, consumer.Status().InFlight)
+		assert.Error(t, consumer.Resume())
+		mockClient.AssertExpectations(t)
+	})
+}
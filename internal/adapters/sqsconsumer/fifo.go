@@ -0,0 +1,86 @@
+package sqsconsumer
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+const (
+	attributeMessageGroupID = "MessageGroupId"
+	attributeSequenceNumber = "SequenceNumber"
+)
+
+// Groups run concurrently, messages within a group run in sequence order and
+// stop at the first failure so later messages are never acked ahead of it.
+func (c *Consumer) processGroups(ctx context.Context, messages []received, process func(ctx context.Context, msg ports.Message) error) error {
+	var wg sync.WaitGroup
+	for _, group := range groupMessages(messages) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(msgs []received) {
+			defer wg.Done()
+
+			for i, m := range msgs {
+				if ctx.Err() != nil {
+					return
+				}
+				if err := c.handleOrdered(ctx, m, process); err != nil {
+					c.release(ctx, msgs[i+1:], 0)
+					return
+				}
+			}
+		}(group)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (c *Consumer) release(ctx context.Context, msgs []received, delay int32) {
+	for _, m := range msgs {
+		_ = c.Nack(ctx, m.Message, nackOptions{
+			DelayBeforeRetrySeconds: delay,
+		})
+	}
+}
+
+func groupMessages(messages []received) [][]received {
+	index := map[string]int{}
+	groups := [][]received{}
+
+	for _, m := range messages {
+		groupID := m.Attributes[attributeMessageGroupID]
+		i, ok := index[groupID]
+		if !ok {
+			i = len(groups)
+			index[groupID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], m)
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return compareSequenceNumbers(group[i].Attributes[attributeSequenceNumber], group[j].Attributes[attributeSequenceNumber]) < 0
+		})
+	}
+
+	return groups
+}
+
+// Sequence numbers are 128-bit and do not fit in an int64.
+func compareSequenceNumbers(a, b string) int {
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if !okA || !okB {
+		return 0
+	}
+	return x.Cmp(y)
+}
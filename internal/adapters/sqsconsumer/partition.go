@@ -0,0 +1,154 @@
+package sqsconsumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+const (
+	attributeSentTimestamp = "SentTimestamp"
+	defaultShards          = 10
+)
+
+type KeyExtractor func(msg ports.Message) string
+
+// NewKeyExtractor builds a KeyExtractor from dot-separated paths into the
+// message envelope, such as "tenant_id" or "body.order_id". The first path
+// resolving to a non-empty value wins.
+func NewKeyExtractor(paths ...string) KeyExtractor {
+	split := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		split = append(split, strings.Split(p, "."))
+	}
+
+	return func(msg ports.Message) string {
+		decoder := json.NewDecoder(bytes.NewReader(msg.Body))
+		decoder.UseNumber()
+
+		var envelope any
+		if err := decoder.Decode(&envelope); err != nil {
+			return ""
+		}
+
+		for _, path := range split {
+			if key := lookup(envelope, path); key != "" {
+				return key
+			}
+		}
+		return ""
+	}
+}
+
+func lookup(value any, path []string) string {
+	for _, segment := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[segment]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// Messages are hashed by key onto a fixed number of shards. Each shard runs
+// its messages one at a time, so messages sharing a key never overlap, and a
+// failure holds back the later messages of that key behind the retry delay.
+func (c *Consumer) processPartitions(ctx context.Context, messages []received, process func(ctx context.Context, msg ports.Message) error) error {
+	var wg sync.WaitGroup
+	for _, shard := range c.partitionMessages(messages) {
+		if len(shard) == 0 {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(msgs []keyedMessage) {
+			defer wg.Done()
+
+			held := map[string]int32{}
+			for _, km := range msgs {
+				if ctx.Err() != nil {
+					return
+				}
+				if delay, ok := held[km.key]; ok {
+					c.release(ctx, []received{km.msg}, delay)
+					continue
+				}
+				err := c.handleOrdered(ctx, km.msg, process)
+				var throttled *throttledError
+				switch {
+				case errors.As(err, &throttled):
+					held[km.key] = throttled.delay
+				case err != nil:
+					held[km.key] = calculateBackoffDelay(int32(km.msg.ReceiveCount))
+				}
+			}
+		}(shard)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+type keyedMessage struct {
+	key string
+	msg received
+}
+
+func (c *Consumer) partitionMessages(messages []received) [][]keyedMessage {
+	shards := make([][]keyedMessage, c.shards)
+
+	sorted := make([]received, len(messages))
+	copy(sorted, messages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sentTimestamp(sorted[i].Message) < sentTimestamp(sorted[j].Message)
+	})
+
+	for _, m := range sorted {
+		key := c.partitionKey(m.Message)
+		if key == "" {
+			key = m.ID
+		}
+		i := shardFor(key, c.shards)
+		shards[i] = append(shards[i], keyedMessage{key: key, msg: m})
+	}
+
+	return shards
+}
+
+func shardFor(key string, shards int) int {
+	h := fnv.New32a()
+	_, _ = fmt.Fprint(h, key)
+	return int(h.Sum32() % uint32(shards))
+}
+
+func sentTimestamp(m ports.Message) int64 {
+	n, _ := strconv.ParseInt(m.Attributes[attributeSentTimestamp], 10, 64)
+	return n
+}
@@ -0,0 +1,159 @@
+package sqsconsumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewKeyExtractor(t *testing.T) {
+	extract := NewKeyExtractor("body.order_id", "body.user_id", "tenant_id")
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "first path", body: `{"tenant_id":"t-1","body":{"order_id":"ord-1","user_id":"u-1"}}`, want: "ord-1"},
+		{name: "falls back to next path", body: `{"tenant_id":"t-1","body":{"user_id":"u-1"}}`, want: "u-1"},
+		{name: "header field", body: `{"tenant_id":"t-1","body":{}}`, want: "t-1"},
+		{name: "numeric value", body: `{"body":{"order_id":12345678901234567890}}`, want: "12345678901234567890"},
+		{name: "missing", body: `{"body":{}}`, want: ""},
+		{name: "invalid json", body: `{invalid-json}`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extract(ports.Message{Body: []byte(tt.body)}))
+		})
+	}
+}
+
+func keyedSQSMessage(id, orderID string, sentAt int) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("handle-" + id),
+		Body:          aws.String(fmt.Sprintf(`{"body":{"order_id":%q}}`, orderID)),
+		Attributes: map[string]string{
+			"ApproximateReceiveCount": "1",
+			"SentTimestamp":           fmt.Sprint(1700000000000 + sentAt),
+		},
+	}
+}
+
+func TestConsumer_ReadPartitioned(t *testing.T) {
+	t.Run("keeps per key order under concurrency", func(t *testing.T) {
+		const keys, perKey = 8, 10
+
+		sqsMessages := []types.Message{}
+		for i := 0; i < perKey; i++ {
+			for k := 0; k < keys; k++ {
+				sqsMessages = append(sqsMessages, keyedSQSMessage(fmt.Sprintf("ord-%d-%d", k, i), fmt.Sprintf("ord-%d", k), i*keys+k))
+			}
+		}
+		rand.Shuffle(len(sqsMessages), func(i, j int) { sqsMessages[i], sqsMessages[j] = sqsMessages[j], sqsMessages[i] })
+
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{
+			QueueURL:     "test-queue",
+			PartitionKey: NewKeyExtractor("body.order_id"),
+			Shards:       4,
+		})
+
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{Messages: sqsMessages}, nil).Once()
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+		mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(keys * perKey)
+
+		var mu sync.Mutex
+		processed := map[string][]string{}
+		inFlight := map[string]int{}
+		running, maxRunning := 0, 0
+		overlapped := false
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			key := NewKeyExtractor("body.order_id")(msg)
+
+			mu.Lock()
+			inFlight[key]++
+			overlapped = overlapped || inFlight[key] > 1
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			time.Sleep(time.Duration(rand.Intn(2000)) * time.Microsecond)
+
+			mu.Lock()
+			inFlight[key]--
+			running--
+			processed[key] = append(processed[key], msg.ID)
+			mu.Unlock()
+			return nil
+		})
+
+		assert.False(t, overlapped)
+		assert.Greater(t, maxRunning, 1)
+		for k := 0; k < keys; k++ {
+			expected := []string{}
+			for i := 0; i < perKey; i++ {
+				expected = append(expected, fmt.Sprintf("ord-%d-%d", k, i))
+			}
+			assert.Equal(t, expected, processed[fmt.Sprintf("ord-%d", k)])
+		}
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("holds back later messages of a failed key", func(t *testing.T) {
+		mockClient := new(MockSQSClient)
+		consumer := NewSqsConsumer(mockClient, Options{
+			QueueURL:     "test-queue",
+			PartitionKey: NewKeyExtractor("body.order_id"),
+			Shards:       1,
+		})
+
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{
+			Messages: []types.Message{
+				keyedSQSMessage("a-1", "a", 1),
+				keyedSQSMessage("b-1", "b", 2),
+				keyedSQSMessage("a-2", "a", 3),
+				keyedSQSMessage("b-2", "b", 4),
+			},
+		}, nil).Once()
+		mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+		mockClient.On("DeleteMessage", mock.Anything, mock.MatchedBy(func(input *sqs.DeleteMessageInput) bool {
+			return *input.ReceiptHandle == "handle-b-1" || *input.ReceiptHandle == "handle-b-2"
+		}), mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(2)
+		mockClient.On("ChangeMessageVisibility", mock.Anything, mock.MatchedBy(func(input *sqs.ChangeMessageVisibilityInput) bool {
+			return (*input.ReceiptHandle == "handle-a-1" || *input.ReceiptHandle == "handle-a-2") && input.VisibilityTimeout == 30
+		}), mock.Anything).Return(&sqs.ChangeMessageVisibilityOutput{}, nil).Times(2)
+
+		processed := []string{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			processed = append(processed, msg.ID)
+			if msg.ID == "a-1" {
+				return errors.New("db error")
+			}
+			return nil
+		})
+
+		assert.Equal(t, []string{"a-1", "b-1", "b-2"}, processed)
+		mockClient.AssertExpectations(t)
+	})
+}
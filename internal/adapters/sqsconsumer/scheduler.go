@@ -0,0 +1,306 @@
+package sqsconsumer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type SchedulingMode string
+
+const (
+	SchedulingWeighted SchedulingMode = "weighted"
+	SchedulingStrict   SchedulingMode = "strict"
+)
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 20 * time.Second
+)
+
+type ScheduledQueue struct {
+	Consumer *Consumer
+	Weight   int
+}
+
+type SchedulerOptions struct {
+	Mode        SchedulingMode
+	Concurrency int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// ReceiveLimit caps how many messages may be in flight across all queues,
+	// as Options.ReceiveLimit does for a single consumer.
+	ReceiveLimit func() int
+}
+
+// Scheduler consumes several queues through one ports.Consumer. With
+// SchedulingStrict it always polls the first queue that is not backing off,
+// in declaration order. With SchedulingWeighted it interleaves polls with a
+// smooth weighted round robin so lower weights are never starved. Queues are
+// short polled, since a long poll on an empty queue would hold up the others;
+// queues that come back empty are skipped for an exponentially growing
+// backoff instead. All queues share a single budget of in-flight messages.
+type Scheduler struct {
+	queues       []*scheduledQueue
+	mode         SchedulingMode
+	budget       chan struct{}
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	receiveLimit func() int
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+type scheduledQueue struct {
+	consumer      *Consumer
+	weight        int
+	currentWeight int
+	emptyPolls    int
+	nextPoll      time.Time
+	// batches counts the queue's batches still being processed.
+	batches atomic.Int32
+}
+
+func NewScheduler(queues []ScheduledQueue, opts SchedulerOptions) *Scheduler {
+	scheduled := make([]*scheduledQueue, 0, len(queues))
+	for _, q := range queues {
+		weight := q.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		scheduled = append(scheduled, &scheduledQueue{consumer: q.Consumer, weight: weight})
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		for _, q := range scheduled {
+			concurrency += int(batchSize(q.consumer))
+		}
+	}
+
+	minBackoff := opts.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff < minBackoff {
+		maxBackoff = max(defaultMaxBackoff, minBackoff)
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = SchedulingWeighted
+	}
+
+	return &Scheduler{
+		queues:       scheduled,
+		mode:         mode,
+		budget:       make(chan struct{}, concurrency),
+		minBackoff:   minBackoff,
+		maxBackoff:   maxBackoff,
+		receiveLimit: opts.ReceiveLimit,
+		now:          time.Now,
+		sleep:        sleepContext,
+	}
+}
+
+func (s *Scheduler) Read(ctx context.Context, process func(ctx context.Context, msg ports.Message) error) error {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		for _, q := range s.queues {
+			stopIfDrained(q)
+		}
+	}()
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		for _, q := range s.queues {
+			stopIfDrained(q)
+		}
+
+		if s.drained() {
+			wg.Wait()
+			for _, q := range s.queues {
+				q.consumer.stop()
+			}
+			return nil
+		}
+
+		limit := -1
+		if s.receiveLimit != nil {
+			if limit = s.receiveLimit(); limit >= 0 {
+				// Batches still in flight may hold some of the limit without
+				// having claimed it yet, so wait for them and read it again.
+				wg.Wait()
+				limit = s.receiveLimit()
+			}
+		}
+		if limit == 0 {
+			if err := s.sleep(ctx, pausePollInterval); err != nil {
+				return err
+			}
+			continue
+		}
+
+		q, wait := s.next()
+		if q == nil {
+			if err := s.sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		reserved := min(int(batchSize(q.consumer)), cap(s.budget))
+		if limit > 0 {
+			reserved = min(reserved, limit)
+		}
+		if err := s.acquire(ctx, reserved); err != nil {
+			return err
+		}
+
+		messages, err := q.consumer.receive(ctx, int32(reserved), 0)
+		if err != nil {
+			s.release(reserved)
+			metrics.ReceiveErrors.Add(q.consumer.name, 1)
+			s.backoff(q)
+			continue
+		}
+		metrics.MessagesReceived.Add(q.consumer.name, int64(len(messages)))
+
+		s.release(reserved - len(messages))
+		if len(messages) == 0 {
+			s.backoff(q)
+			continue
+		}
+		q.emptyPolls = 0
+		q.nextPoll = time.Time{}
+
+		wg.Add(1)
+		q.batches.Add(1)
+		go func(q *scheduledQueue, msgs []ports.Message, reserved int) {
+			defer wg.Done()
+			defer q.batches.Add(-1)
+			defer s.release(reserved)
+			_ = q.consumer.processBatch(ctx, msgs, process)
+		}(q, messages, len(messages))
+	}
+}
+
+// next returns the queue to poll, or how long to wait when every queue is
+// backing off.
+func (s *Scheduler) next() (*scheduledQueue, time.Duration) {
+	now := s.now()
+
+	eligible := make([]*scheduledQueue, 0, len(s.queues))
+	var wait time.Duration
+	for _, q := range s.queues {
+		if q.consumer.runState() != ports.RunStateRunning {
+			continue
+		}
+		if q.nextPoll.After(now) {
+			if d := q.nextPoll.Sub(now); wait == 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+		eligible = append(eligible, q)
+	}
+
+	if len(eligible) == 0 {
+		if wait == 0 {
+			wait = pausePollInterval
+		}
+		return nil, wait
+	}
+
+	if s.mode == SchedulingStrict {
+		return eligible[0], 0
+	}
+
+	total := 0
+	var best *scheduledQueue
+	for _, q := range eligible {
+		q.currentWeight += q.weight
+		total += q.weight
+		if best == nil || q.currentWeight > best.currentWeight {
+			best = q
+		}
+	}
+	best.currentWeight -= total
+
+	return best, 0
+}
+
+func (s *Scheduler) drained() bool {
+	for _, q := range s.queues {
+		switch q.consumer.runState() {
+		case ports.RunStateRunning, ports.RunStatePaused:
+			return false
+		}
+	}
+	return true
+}
+
+// stopIfDrained moves a draining queue to stopped once none of its batches
+// is still being processed, as Consumer.Read does on its own. Only Read's
+// loop calls it, so no batch of the queue can start meanwhile.
+func stopIfDrained(q *scheduledQueue) {
+	if q.batches.Load() == 0 && q.consumer.runState() == ports.RunStateDraining {
+		q.consumer.stop()
+	}
+}
+
+func (s *Scheduler) backoff(q *scheduledQueue) {
+	delay := s.minBackoff << min(q.emptyPolls, 16)
+	if delay > s.maxBackoff || delay <= 0 {
+		delay = s.maxBackoff
+	}
+	q.emptyPolls++
+	q.nextPoll = s.now().Add(delay)
+}
+
+func (s *Scheduler) acquire(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		select {
+		case s.budget <- struct{}{}:
+		case <-ctx.Done():
+			s.release(i)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) release(n int) {
+	for i := 0; i < n; i++ {
+		<-s.budget
+	}
+}
+
+func batchSize(c *Consumer) int32 {
+	if c.maxMessages <= 0 {
+		return 1
+	}
+	return c.maxMessages
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
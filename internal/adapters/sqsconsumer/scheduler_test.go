@@ -0,0 +1,293 @@
+package sqsconsumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSQSClient struct {
+	mu        sync.Mutex
+	batches   map[string][][]types.Message
+	endless   map[string]bool
+	polls     []string
+	requested []int32
+	waits     []int32
+	onPoll    func(polls int)
+}
+
+func (f *fakeSQSClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	queue := *params.QueueUrl
+	f.polls = append(f.polls, queue)
+	f.requested = append(f.requested, params.MaxNumberOfMessages)
+	f.waits = append(f.waits, params.WaitTimeSeconds)
+	polls := len(f.polls)
+
+	var messages []types.Message
+	switch {
+	case f.endless[queue]:
+		for i := int32(0); i < params.MaxNumberOfMessages; i++ {
+			id := fmt.Sprintf("%s-%d-%d", queue, polls, i)
+			messages = append(messages, types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String(id)})
+		}
+	case len(f.batches[queue]) > 0:
+		messages = f.batches[queue][0]
+		f.batches[queue] = f.batches[queue][1:]
+	}
+	f.mu.Unlock()
+
+	if f.onPoll != nil {
+		f.onPoll(polls)
+	}
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (f *fakeSQSClient) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQSClient) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func fakeBatch(queue string, size int) []types.Message {
+	messages := []types.Message{}
+	for i := 0; i < size; i++ {
+		id := fmt.Sprintf("%s-%d", queue, i)
+		messages = append(messages, types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String(id)})
+	}
+	return messages
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestScheduler(client SQSClient, opts SchedulerOptions, weights map[string]int, order ...string) (*Scheduler, *fakeClock) {
+	queues := []ScheduledQueue{}
+	for _, name := range order {
+		queues = append(queues, ScheduledQueue{
+			Consumer: NewSqsConsumer(client, Options{Name: name, QueueURL: name, MaxMessages: 2}),
+			Weight:   weights[name],
+		})
+	}
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	scheduler := NewScheduler(queues, opts)
+	scheduler.now = clock.Now
+	return scheduler, clock
+}
+
+func noopProcess(ctx context.Context, msg ports.Message) error {
+	return nil
+}
+
+func TestScheduler_Strict(t *testing.T) {
+	client := &fakeSQSClient{batches: map[string][][]types.Message{
+		"critical": {fakeBatch("critical", 2), fakeBatch("critical", 2)},
+		"bulk":     {fakeBatch("bulk", 2), fakeBatch("bulk", 1)},
+	}}
+
+	scheduler, _ := newTestScheduler(client, SchedulerOptions{Mode: SchedulingStrict}, nil, "critical", "bulk")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	err := scheduler.Read(ctx, noopProcess)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"critical", "critical", "critical", "bulk", "bulk", "bulk"}, client.polls)
+}
+
+func TestScheduler_Weighted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &fakeSQSClient{
+		endless: map[string]bool{"critical": true, "bulk": true},
+		onPoll: func(polls int) {
+			if polls == 8 {
+				cancel()
+			}
+		},
+	}
+
+	scheduler, _ := newTestScheduler(client, SchedulerOptions{Mode: SchedulingWeighted}, map[string]int{"critical": 3, "bulk": 1}, "critical", "bulk")
+
+	_ = scheduler.Read(ctx, noopProcess)
+
+	assert.Equal(t, []string{
+		"critical", "critical", "bulk", "critical",
+		"critical", "critical", "bulk", "critical",
+	}, client.polls)
+}
+
+func TestScheduler_ShortPolls(t *testing.T) {
+	client := &fakeSQSClient{batches: map[string][][]types.Message{
+		"bulk": {fakeBatch("bulk", 1)},
+	}}
+
+	scheduler := NewScheduler([]ScheduledQueue{
+		{Consumer: NewSqsConsumer(client, Options{Name: "critical", QueueURL: "critical", MaxMessages: 2, WaitTimeSec: 20})},
+		{Consumer: NewSqsConsumer(client, Options{Name: "bulk", QueueURL: "bulk", MaxMessages: 2, WaitTimeSec: 20})},
+	}, SchedulerOptions{Mode: SchedulingStrict})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	_ = scheduler.Read(ctx, noopProcess)
+
+	assert.Equal(t, []string{"critical", "bulk", "bulk"}, client.polls)
+	assert.Equal(t, []int32{0, 0, 0}, client.waits)
+}
+
+func TestScheduler_ReceiveLimit(t *testing.T) {
+	client := &fakeSQSClient{endless: map[string]bool{"critical": true}}
+
+	scheduler, _ := newTestScheduler(client, SchedulerOptions{
+		Mode:         SchedulingStrict,
+		ReceiveLimit: func() int { return 1 },
+	}, nil, "critical")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.onPoll = func(polls int) {
+		if polls == 3 {
+			cancel()
+		}
+	}
+
+	_ = scheduler.Read(ctx, noopProcess)
+
+	assert.Equal(t, []int32{1, 1, 1}, client.requested)
+}
+
+func TestScheduler_Drain(t *testing.T) {
+	t.Run("stops a drained queue while the others keep running", func(t *testing.T) {
+		client := &fakeSQSClient{endless: map[string]bool{"critical": true, "bulk": true}}
+		scheduler, _ := newTestScheduler(client, SchedulerOptions{}, nil, "critical", "bulk")
+		critical, bulk := scheduler.queues[0].consumer, scheduler.queues[1].consumer
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		client.onPoll = func(polls int) {
+			switch polls {
+			case 2:
+				bulk.Drain()
+			case 20:
+				assert.Equal(t, ports.RunStateStopped, bulk.Status().State)
+				cancel()
+			}
+		}
+
+		_ = scheduler.Read(ctx, noopProcess)
+
+		assert.Equal(t, ports.RunStateRunning, critical.Status().State)
+		assert.Equal(t, ports.RunStateStopped, bulk.Status().State)
+	})
+
+	t.Run("stops a queue drained during its last batch when Read returns", func(t *testing.T) {
+		client := &fakeSQSClient{endless: map[string]bool{"critical": true}}
+		scheduler, _ := newTestScheduler(client, SchedulerOptions{}, nil, "critical", "bulk")
+		critical := scheduler.queues[0].consumer
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_ = scheduler.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+			critical.Drain()
+			cancel()
+			return nil
+		})
+
+		assert.Equal(t, ports.RunStateStopped, critical.Status().State)
+	})
+}
+
+func TestScheduler_Backoff(t *testing.T) {
+	scheduler, clock := newTestScheduler(&fakeSQSClient{}, SchedulerOptions{
+		Mode:       SchedulingStrict,
+		MinBackoff: time.Second,
+		MaxBackoff: 4 * time.Second,
+	}, nil, "critical", "bulk")
+
+	critical := scheduler.queues[0]
+
+	scheduler.backoff(critical)
+	q, _ := scheduler.next()
+	assert.Equal(t, "bulk", q.consumer.Name())
+
+	scheduler.backoff(q)
+	q, wait := scheduler.next()
+	assert.Nil(t, q)
+	assert.Equal(t, time.Second, wait)
+
+	clock.now = clock.now.Add(time.Second)
+	q, _ = scheduler.next()
+	assert.Equal(t, "critical", q.consumer.Name())
+
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for _, delay := range expected {
+		scheduler.backoff(critical)
+		assert.Equal(t, clock.now.Add(delay), critical.nextPoll)
+	}
+}
+
+func TestScheduler_SharedBudget(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &fakeSQSClient{
+		endless: map[string]bool{"critical": true, "bulk": true},
+		onPoll: func(polls int) {
+			if polls == 20 {
+				cancel()
+			}
+		},
+	}
+
+	scheduler, _ := newTestScheduler(client, SchedulerOptions{Concurrency: 1}, nil, "critical", "bulk")
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	_ = scheduler.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	assert.Equal(t, 1, maxRunning)
+	for _, requested := range client.requested {
+		assert.Equal(t, int32(1), requested)
+	}
+}
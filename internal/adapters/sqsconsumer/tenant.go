@@ -0,0 +1,72 @@
+package sqsconsumer
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
+)
+
+type throttledError struct {
+	tenant string
+	delay  int32
+}
+
+func (e *throttledError) Error() string {
+	return fmt.Sprintf("tenant %s is over its rate limit", e.tenant)
+}
+
+// throttle nacks the message with a short delay when its tenant has no tokens
+// left, without running it through the processor.
+func (c *Consumer) throttle(ctx context.Context, r received) *throttledError {
+	if c.limiter == nil || r.err != nil {
+		return nil
+	}
+
+	tenant := c.tenantKey(r.Message)
+	allowed, wait := c.limiter.Allow(tenant)
+	if allowed {
+		metrics.TenantAllowed.Add(metrics.TenantKey(tenant), 1)
+		return nil
+	}
+
+	metrics.TenantThrottled.Add(metrics.TenantKey(tenant), 1)
+	delay := int32(max(1, math.Ceil(wait.Seconds())))
+	_ = c.Nack(ctx, r.Message, nackOptions{
+		DelayBeforeRetrySeconds: delay,
+	})
+
+	return &throttledError{tenant: tenant, delay: delay}
+}
+
+// interleaveTenants reorders a batch round robin across tenants, keeping the
+// relative order of each tenant's messages, so a tenant that floods the queue
+// cannot take every token and slot ahead of the others.
+func (c *Consumer) interleaveTenants(messages []received) []received {
+	index := map[string]int{}
+	queues := [][]received{}
+	for _, m := range messages {
+		tenant := c.tenantKey(m.Message)
+		i, ok := index[tenant]
+		if !ok {
+			i = len(queues)
+			index[tenant] = i
+			queues = append(queues, nil)
+		}
+		queues[i] = append(queues[i], m)
+	}
+
+	interleaved := make([]received, 0, len(messages))
+	for len(interleaved) < len(messages) {
+		for i, q := range queues {
+			if len(q) == 0 {
+				continue
+			}
+			interleaved = append(interleaved, q[0])
+			queues[i] = q[1:]
+		}
+	}
+
+	return interleaved
+}
@@ -0,0 +1,126 @@
+package sqsconsumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/fsblob"
+	"github.com/guilherme-daniel-rs/event-processor/internal/claimcheck"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func tenantMessage(id, tenant string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("handle-" + id),
+		Body:          aws.String(fmt.Sprintf(`{"tenant_id":%q}`, tenant)),
+		Attributes:    map[string]string{"ApproximateReceiveCount": "1"},
+	}
+}
+
+func TestConsumer_InterleaveTenants(t *testing.T) {
+	consumer := NewSqsConsumer(new(MockSQSClient), Options{})
+
+	messages := []received{}
+	for _, m := range []types.Message{
+		tenantMessage("a-1", "a"), tenantMessage("a-2", "a"), tenantMessage("a-3", "a"),
+		tenantMessage("b-1", "b"), tenantMessage("a-4", "a"), tenantMessage("c-1", "c"),
+		tenantMessage("b-2", "b"),
+	} {
+		messages = append(messages, received{Message: toPortsMessage(m)})
+	}
+
+	ids := []string{}
+	for _, m := range consumer.interleaveTenants(messages) {
+		ids = append(ids, m.ID)
+	}
+
+	assert.Equal(t, []string{"a-1", "b-1", "c-1", "a-2", "b-2", "a-3", "a-4"}, ids)
+}
+
+func TestConsumer_TenantRateLimit(t *testing.T) {
+	mockClient := new(MockSQSClient)
+	consumer := NewSqsConsumer(mockClient, Options{
+		QueueURL:      "test-queue",
+		TenantLimiter: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.5, Burst: 2}, nil),
+	})
+
+	mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{
+		Messages: []types.Message{
+			tenantMessage("noisy-1", "noisy"), tenantMessage("noisy-2", "noisy"),
+			tenantMessage("noisy-3", "noisy"), tenantMessage("noisy-4", "noisy"),
+			tenantMessage("quiet-1", "quiet"),
+		},
+	}, nil).Once()
+	mockClient.On("ReceiveMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{}, nil).Maybe()
+	mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(3)
+	mockClient.On("ChangeMessageVisibility", mock.Anything, mock.MatchedBy(func(input *sqs.ChangeMessageVisibilityInput) bool {
+		return (*input.ReceiptHandle == "handle-noisy-3" || *input.ReceiptHandle == "handle-noisy-4") &&
+			input.VisibilityTimeout >= 1 && input.VisibilityTimeout <= 2
+	}), mock.Anything).Return(&sqs.ChangeMessageVisibilityOutput{}, nil).Times(2)
+
+	var mu sync.Mutex
+	processed := []string{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_ = consumer.Read(ctx, func(ctx context.Context, msg ports.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, msg.ID)
+		return nil
+	})
+
+	assert.ElementsMatch(t, []string{"noisy-1", "noisy-2", "quiet-1"}, processed)
+	mockClient.AssertExpectations(t)
+}
+
+func TestConsumer_TenantOfEncodedBodies(t *testing.T) {
+	store := fsblob.NewBlobStore(t.TempDir())
+	assert.NoError(t, store.Put(context.Background(), "payloads", "key-1", []byte(`{"tenant_id":"a"}`)))
+	pointer, err := claimcheck.Encode(claimcheck.Pointer{Bucket: "payloads", Key: "key-1"})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(`{"tenant_id":"b"}`))
+	_ = w.Close()
+
+	mockClient := new(MockSQSClient)
+	mockClient.On("DeleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(&sqs.DeleteMessageOutput{}, nil).Times(2)
+
+	consumer := NewSqsConsumer(mockClient, Options{
+		QueueURL:      "test-queue",
+		TenantLimiter: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.5, Burst: 1}, nil),
+		BlobStore:     store,
+	})
+
+	var mu sync.Mutex
+	processed := []string{}
+	err = consumer.processBatch(context.Background(), []ports.Message{
+		{ID: "claim-check", Body: pointer, AckToken: "handle-1"},
+		{ID: "gzip", Body: []byte(base64.StdEncoding.EncodeToString(buf.Bytes())), AckToken: "handle-2", MessageAttributes: map[string]string{"content-encoding": "gzip"}},
+	}, func(ctx context.Context, msg ports.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, msg.ID)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"claim-check", "gzip"}, processed)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,158 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type Options struct {
+	Name             string
+	Initial          int
+	Min              int
+	Max              int
+	BackoffRatio     float64
+	LatencyTolerance float64
+}
+
+// Limiter is an AIMD concurrency limiter. Every completed call is a sample:
+// throttling and timeouts, or a latency above LatencyTolerance times the
+// lowest latency seen, shrink the limit by BackoffRatio. A healthy sample
+// taken while at least half the limit was in use grows it by one. Other
+// errors say nothing about downstream load and are not samples.
+type Limiter struct {
+	mu               sync.Mutex
+	limit            float64
+	inFlight         int
+	changed          chan struct{}
+	min              float64
+	max              float64
+	backoffRatio     float64
+	latencyTolerance float64
+	minLatency       time.Duration
+	limitVar         *expvar.Float
+}
+
+func NewLimiter(opts Options) *Limiter {
+	minLimit := max(1, opts.Min)
+	maxLimit := max(minLimit, opts.Max)
+	initial := min(max(opts.Initial, minLimit), maxLimit)
+
+	backoffRatio := opts.BackoffRatio
+	if backoffRatio <= 0 || backoffRatio >= 1 {
+		backoffRatio = 0.9
+	}
+	latencyTolerance := opts.LatencyTolerance
+	if latencyTolerance <= 1 {
+		latencyTolerance = 2
+	}
+
+	l := &Limiter{
+		limit:            float64(initial),
+		changed:          make(chan struct{}),
+		min:              float64(minLimit),
+		max:              float64(maxLimit),
+		backoffRatio:     backoffRatio,
+		latencyTolerance: latencyTolerance,
+		limitVar:         new(expvar.Float),
+	}
+
+	l.limitVar.Set(l.limit)
+	metrics.ConcurrencyLimit.Set(opts.Name, l.limitVar)
+
+	return l
+}
+
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+func (l *Limiter) Wrap(process func(ctx context.Context, msg ports.Message) error) func(ctx context.Context, msg ports.Message) error {
+	return func(ctx context.Context, msg ports.Message) error {
+		inFlight, err := l.acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		err = process(ctx, msg)
+		l.release(inFlight, time.Since(start), err)
+
+		return err
+	}
+}
+
+func (l *Limiter) acquire(ctx context.Context) (int, error) {
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			inFlight := l.inFlight
+			l.mu.Unlock()
+			return inFlight, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (l *Limiter) release(inFlight int, latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if err == nil || congested(err) {
+		l.sample(inFlight, latency, err != nil)
+	}
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// congested reports the errors that mean downstream is overloaded:
+// throttling and timeouts.
+func congested(err error) bool {
+	if errors.Is(err, ports.ErrThrottled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+func (l *Limiter) sample(inFlight int, latency time.Duration, failed bool) {
+	if l.minLatency == 0 || latency < l.minLatency {
+		l.minLatency = latency
+	}
+
+	slow := float64(latency) > float64(l.minLatency)*l.latencyTolerance
+	if slow && l.limit <= l.min {
+		// Still slow at the minimum limit, so downstream itself got slower:
+		// take this latency as the new baseline instead of staying stuck.
+		l.minLatency = latency
+		slow = false
+	}
+	switch {
+	case failed || slow:
+		l.limit = math.Max(l.min, l.limit*l.backoffRatio)
+	case float64(inFlight)*2 >= l.limit:
+		l.limit = math.Min(l.max, l.limit+1)
+	}
+
+	l.limitVar.Set(math.Floor(l.limit))
+}
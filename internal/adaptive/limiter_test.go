@@ -0,0 +1,160 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+// simulate drives the limiter with a fully saturated workload, where every
+// slot the limit allows is used, against a downstream described by respond.
+func simulate(l *Limiter, steps int, respond func(inFlight int) (time.Duration, bool)) []int {
+	limits := make([]int, 0, steps)
+	for i := 0; i < steps; i++ {
+		inFlight := l.Limit()
+		latency, failed := respond(inFlight)
+
+		l.mu.Lock()
+		l.sample(inFlight, latency, failed)
+		l.mu.Unlock()
+
+		limits = append(limits, l.Limit())
+	}
+	return limits
+}
+
+func TestLimiter_Simulation(t *testing.T) {
+	t.Run("grows to the maximum while downstream is healthy", func(t *testing.T) {
+		l := NewLimiter(Options{Name: t.Name(), Initial: 5, Min: 1, Max: 50})
+
+		limits := simulate(l, 100, func(int) (time.Duration, bool) {
+			return 10 * time.Millisecond, false
+		})
+
+		assert.Equal(t, 50, limits[len(limits)-1])
+	})
+
+	t.Run("settles around the throttling capacity", func(t *testing.T) {
+		const capacity = 20
+		l := NewLimiter(Options{Name: t.Name(), Initial: 5, Min: 1, Max: 100})
+
+		limits := simulate(l, 2000, func(inFlight int) (time.Duration, bool) {
+			return 10 * time.Millisecond, inFlight > capacity
+		})
+
+		for _, limit := range limits[200:] {
+			assert.GreaterOrEqual(t, limit, capacity*8/10)
+			assert.LessOrEqual(t, limit, capacity+1)
+		}
+	})
+
+	t.Run("backs off when latency degrades without errors", func(t *testing.T) {
+		const capacity = 10
+		l := NewLimiter(Options{Name: t.Name(), Initial: 5, Min: 1, Max: 100, LatencyTolerance: 1.5})
+
+		limits := simulate(l, 2000, func(inFlight int) (time.Duration, bool) {
+			latency := 10 * time.Millisecond
+			if inFlight > capacity {
+				latency = latency * time.Duration(inFlight) / capacity
+			}
+			return latency, false
+		})
+
+		for _, limit := range limits[200:] {
+			assert.GreaterOrEqual(t, limit, capacity)
+			assert.LessOrEqual(t, limit, capacity*3/2+1)
+		}
+	})
+
+	t.Run("recovers after an outage", func(t *testing.T) {
+		l := NewLimiter(Options{Name: t.Name(), Initial: 40, Min: 2, Max: 40})
+
+		limits := simulate(l, 50, func(int) (time.Duration, bool) {
+			return 10 * time.Millisecond, true
+		})
+		assert.Equal(t, 2, limits[len(limits)-1])
+
+		limits = simulate(l, 50, func(int) (time.Duration, bool) {
+			return 10 * time.Millisecond, false
+		})
+		assert.Equal(t, 40, limits[len(limits)-1])
+	})
+}
+
+func TestLimiter_Rebaseline(t *testing.T) {
+	l := NewLimiter(Options{Name: t.Name(), Initial: 1, Min: 1, Max: 10})
+
+	simulate(l, 10, func(int) (time.Duration, bool) {
+		return 10 * time.Millisecond, false
+	})
+	limits := simulate(l, 50, func(int) (time.Duration, bool) {
+		return 50 * time.Millisecond, false
+	})
+
+	assert.Equal(t, 50*time.Millisecond, l.minLatency)
+	assert.Equal(t, 10, limits[len(limits)-1])
+}
+
+func TestLimiter_Wrap(t *testing.T) {
+	t.Run("blocks calls over the limit", func(t *testing.T) {
+		l := NewLimiter(Options{Name: t.Name(), Initial: 1, Min: 1, Max: 1})
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		process := l.Wrap(func(ctx context.Context, msg ports.Message) error {
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+
+		go func() { _ = process(context.Background(), ports.Message{}) }()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, process(ctx, ports.Message{}), context.DeadlineExceeded)
+
+		close(finish)
+	})
+
+	t.Run("shrinks on throttling but ignores validation failures", func(t *testing.T) {
+		l := NewLimiter(Options{Name: t.Name(), Initial: 10, Min: 1, Max: 10})
+
+		invalid := l.Wrap(func(ctx context.Context, msg ports.Message) error {
+			return ports.NewNonRetriableError(errors.New("invalid message header"))
+		})
+		for i := 0; i < 5; i++ {
+			_ = invalid(context.Background(), ports.Message{})
+		}
+		assert.Equal(t, 10, l.Limit())
+
+		throttled := l.Wrap(func(ctx context.Context, msg ports.Message) error {
+			return ports.ErrThrottled
+		})
+		_ = throttled(context.Background(), ports.Message{})
+		assert.Equal(t, 9, l.Limit())
+
+		timedOut := l.Wrap(func(ctx context.Context, msg ports.Message) error {
+			return fmt.Errorf("failed to save event: %w", context.DeadlineExceeded)
+		})
+		_ = timedOut(context.Background(), ports.Message{})
+		assert.Equal(t, 8, l.Limit())
+	})
+
+	t.Run("does not shrink on ordinary retriable errors", func(t *testing.T) {
+		l := NewLimiter(Options{Name: t.Name(), Initial: 10, Min: 1, Max: 10})
+
+		failing := l.Wrap(func(ctx context.Context, msg ports.Message) error {
+			return errors.New("connection reset by peer")
+		})
+		for i := 0; i < 5; i++ {
+			_ = failing(context.Background(), ports.Message{})
+		}
+		assert.Equal(t, 10, l.Limit())
+	})
+}
@@ -3,27 +3,64 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
 	"github.com/guilherme-daniel-rs/event-processor/internal/domain/models"
 	"github.com/guilherme-daniel-rs/event-processor/internal/logging"
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
 	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
 )
 
 type Processor struct {
 	schemaRegistry *events.SchemaRegistry
 	repository     ports.EventRepository
+	now            func() time.Time
+	maxClockSkew   time.Duration
+	maxEventAge    time.Duration
+}
+
+// Options configures a Processor. MaxClockSkew is how far in the future
+// occurred_at may be; MaxEventAge, when set, quarantines older events. Now
+// defaults to time.Now.
+type Options struct {
+	Registry     *events.SchemaRegistry
+	Now          func() time.Time
+	MaxClockSkew time.Duration
+	MaxEventAge  time.Duration
 }
 
 func NewProcessor(repository ports.EventRepository) *Processor {
+	return NewProcessorWithRegistry(repository, events.NewSchemaRegistry())
+}
+
+func NewProcessorWithRegistry(repository ports.EventRepository, schemaRegistry *events.SchemaRegistry) *Processor {
+	return NewProcessorWithOptions(repository, Options{Registry: schemaRegistry, MaxClockSkew: DefaultMaxClockSkew})
+}
+
+func NewProcessorWithOptions(repository ports.EventRepository, opts Options) *Processor {
+	if opts.Registry == nil {
+		opts.Registry = events.NewSchemaRegistry()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
 	return &Processor{
-		schemaRegistry: events.NewSchemaRegistry(),
+		schemaRegistry: opts.Registry,
 		repository:     repository,
+		now:            opts.Now,
+		maxClockSkew:   opts.MaxClockSkew,
+		maxEventAge:    opts.MaxEventAge,
 	}
 }
 
+const DefaultMaxClockSkew = 5 * time.Minute
+
 type MessageHeader struct {
 	EventID       string          `json:"event_id"`
 	EventType     string          `json:"event_type"`
@@ -31,6 +68,7 @@ type MessageHeader struct {
 	ClientID      string          `json:"client_id"`
 	SchemaVersion string          `json:"schema_version"`
 	OccurredAt    string          `json:"occurred_at"`
+	ContentType   string          `json:"content_type,omitempty"`
 	Body          json.RawMessage `json:"body"`
 }
 
@@ -44,7 +82,15 @@ func (m MessageHeader) IsValid() bool {
 		len(m.Body) > 0
 }
 
+// SentAtAttribute is the message attribute producers set to the time they
+// sent the message, in Unix milliseconds, for the ingest lag metric.
+const SentAtAttribute = "sent-at"
+
+const sentTimestampAttribute = "SentTimestamp"
+
 func (p *Processor) Process(ctx context.Context, msg ports.Message) error {
+	now := p.now()
+
 	var header MessageHeader
 	if err := json.Unmarshal(msg.Body, &header); err != nil {
 		return ports.NewNonRetriableError(fmt.Errorf("failed to unmarshal message header: %w", err))
@@ -69,24 +115,149 @@ func (p *Processor) Process(ctx context.Context, msg ports.Message) error {
 	}
 	logging.Append(ctx, "Header validated")
 
-	_, err := p.schemaRegistry.Unmarshal(header.EventType, header.SchemaVersion, header.Body)
+	occurredAt, err := time.Parse(time.RFC3339Nano, header.OccurredAt)
+	if err != nil {
+		logging.Append(ctx, "Invalid occurred_at %q", header.OccurredAt)
+		return p.saveFailure(ctx, record, fmt.Errorf("occurred_at must be an RFC 3339 timestamp: %w", err))
+	}
+	occurredAt = occurredAt.UTC()
+	record.OccurredAt = occurredAt.Format(time.RFC3339Nano)
+
+	// Skew and age are measured against when the message was sent, so time
+	// spent in retries does not push a redelivered event past the limit.
+	sent := sentAt(msg, now)
+	if skew := occurredAt.Sub(sent); skew > p.maxClockSkew {
+		logging.Append(ctx, "occurred_at is %s in the future", skew)
+		return p.saveFailure(ctx, record, fmt.Errorf("occurred_at %s is %s in the future, more than the allowed clock skew of %s", record.OccurredAt, skew, p.maxClockSkew))
+	}
+	if age := sent.Sub(occurredAt); p.maxEventAge > 0 && age > p.maxEventAge {
+		logging.Append(ctx, "Event is %s old, older than %s, quarantining", age, p.maxEventAge)
+		return p.quarantine(ctx, record, header.EventType)
+	}
+
+	contentType := msg.MessageAttributes["content-type"]
+	if contentType == "" {
+		contentType = header.ContentType
+	}
+
+	data, err := eventBody(contentType, header.Body)
+	if err != nil {
+		logging.Append(ctx, "Body decode failed: %v", err)
+		return p.saveFailure(ctx, record, err)
+	}
+
+	event, warnings, err := p.schemaRegistry.DecodeWithWarnings(header.EventType, header.SchemaVersion, contentType, data)
 	if err != nil {
 		logging.Append(ctx, "Body unmarshal failed: %v", err)
 		return p.saveFailure(ctx, record, fmt.Errorf("failed to unmarshal event body: %w", err))
 	}
 	logging.Append(ctx, "Body unmarshaled and validated via registry")
 
-	if err := p.repository.Save(ctx, record); err != nil {
+	record.Warnings = warnings
+	for _, warning := range warnings {
+		logging.Append(ctx, "Decode warning at %s [%s]: %s", warning.Path, warning.Rule, warning.Message)
+	}
+
+	event, version, err := p.schemaRegistry.Upcast(header.EventType, header.SchemaVersion, event)
+	if err != nil {
+		logging.Append(ctx, "Upcast failed: %v", err)
+		return p.saveFailure(ctx, record, err)
+	}
+	if version != header.SchemaVersion {
+		upcastBody, err := json.Marshal(event)
+		if err != nil {
+			return p.saveFailure(ctx, record, fmt.Errorf("failed to marshal upcast event: %w", err))
+		}
+		record.UpcastVersion = version
+		record.UpcastBody = string(upcastBody)
+		logging.Append(ctx, "Event upcast from %s to %s", header.SchemaVersion, version)
+	}
+
+	if err := p.save(ctx, record); err != nil {
 		return fmt.Errorf("failed to save event to repository: %w", err)
 	}
 	logging.Append(ctx, "Event saved successfully to repository")
 
+	p.observeLag(msg, header.EventType, occurredAt)
+	return nil
+}
+
+// sentAt is when the message was first sent: SQS's SentTimestamp, which
+// stays the same across redeliveries, else the producer's sent-at attribute,
+// else now.
+func sentAt(msg ports.Message, now time.Time) time.Time {
+	for _, value := range []string{msg.Attributes[sentTimestampAttribute], msg.MessageAttributes[SentAtAttribute]} {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.UnixMilli(ms)
+		}
+	}
+	return now
+}
+
+// observeLag records how long ago the event was sent and how long ago it
+// occurred. Messages without a valid sent-at attribute only count for the
+// event lag.
+func (p *Processor) observeLag(msg ports.Message, eventType string, occurredAt time.Time) {
+	now := p.now()
+	metrics.ObserveDuration(metrics.EventLag, eventType, now.Sub(occurredAt))
+
+	sentAt, err := strconv.ParseInt(msg.MessageAttributes[SentAtAttribute], 10, 64)
+	if err != nil {
+		return
+	}
+	metrics.ObserveDuration(metrics.IngestLag, eventType, now.Sub(time.UnixMilli(sentAt)))
+}
+
+// eventBody returns the body to hand to the schema codec. Binary formats
+// cannot be embedded in the JSON envelope directly, so they travel as a
+// base64 string.
+func eventBody(contentType string, body json.RawMessage) ([]byte, error) {
+	if events.NormalizeContentType(contentType) == events.ContentTypeJSON {
+		return body, nil
+	}
+
+	var encoded []byte
+	if err := json.Unmarshal(body, &encoded); err != nil {
+		return nil, fmt.Errorf("%s body must be a base64 string: %w", contentType, err)
+	}
+	return encoded, nil
+}
+
+// save stamps ProcessedAt just before the record is written, so it covers
+// the whole of processing.
+func (p *Processor) save(ctx context.Context, record models.EventRecord) error {
+	record.ProcessedAt = p.now().UTC().Format(time.RFC3339Nano)
+	return p.repository.Save(ctx, record)
+}
+
+// quarantine stores a stale event without processing it. The message is
+// acknowledged, since a retry only makes it older.
+func (p *Processor) quarantine(ctx context.Context, record models.EventRecord, eventType string) error {
+	record.Status = "quarantined"
+	if err := p.save(ctx, record); err != nil {
+		return fmt.Errorf("failed to save quarantined event record: %w", err)
+	}
+	// The type has not been checked yet, and metric keys must not come from
+	// arbitrary producer input.
+	if !p.schemaRegistry.Known(eventType) {
+		eventType = "unknown"
+	}
+	metrics.EventsQuarantined.Add(eventType, 1)
 	return nil
 }
 
 func (p *Processor) saveFailure(ctx context.Context, record models.EventRecord, err error) error {
 	record.Status = "failed"
-	if saveErr := p.repository.Save(ctx, record); saveErr != nil {
+
+	var validationErr *events.ValidationError
+	if errors.As(err, &validationErr) {
+		record.ValidationIssues = validationErr.Issues
+		for _, issue := range validationErr.Issues {
+			logging.Append(ctx, "Validation issue at %s [%s]: %s", issue.Path, issue.Rule, issue.Message)
+		}
+	}
+
+	if saveErr := p.save(ctx, record); saveErr != nil {
 		return fmt.Errorf("failed to save failed event record: %w (original error: %v)", saveErr, err)
 	}
 	return ports.NewNonRetriableError(err)
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/guilherme-daniel-rs/event-processor/internal/app"
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
 	"github.com/guilherme-daniel-rs/event-processor/internal/domain/models"
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
 	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -24,6 +28,11 @@ func (m *MockEventRepository) Save(ctx context.Context, event models.EventRecord
 	return args.Error(0)
 }
 
+type userCreatedV2 struct {
+	events.UserCreatedV1
+	Locale string `json:"locale,omitempty"`
+}
+
 func generateValidBody() ([]byte, map[string]any) {
 	bodyMap := map[string]any{
 		"user_id":  gofakeit.UUID(),
@@ -130,7 +139,13 @@ func TestProcessor_Process(t *testing.T) {
 		}
 
 		repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
-			return e.EventID == eventID && e.Status == "failed"
+			return e.EventID == eventID &&
+				e.Status == "failed" &&
+				assert.ObjectsAreEqual([]events.ValidationIssue{
+					{Path: "$.email", Rule: events.RuleRequired, Message: "is required"},
+					{Path: "$.name", Rule: events.RuleRequired, Message: "is required"},
+					{Path: "$.role", Rule: events.RuleRequired, Message: "is required"},
+				}, e.ValidationIssues)
 		})).Return(nil)
 
 		err := processor.Process(context.Background(), createMessage(header))
@@ -139,6 +154,134 @@ func TestProcessor_Process(t *testing.T) {
 		repo.AssertExpectations(t)
 	})
 
+	t.Run("protobuf body named by message attribute", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		processor := app.NewProcessor(repo)
+
+		_, bodyMap := generateValidBody()
+		codec, err := events.NewSchemaRegistry().Codec("user.created", "v1", events.ContentTypeProtobuf)
+		assert.NoError(t, err)
+		encoded, err := codec.Encode(bodyMap)
+		assert.NoError(t, err)
+		body, _ := json.Marshal(encoded)
+
+		header := app.MessageHeader{
+			EventID:       gofakeit.UUID(),
+			EventType:     "user.created",
+			SchemaVersion: "v1",
+			TenantID:      gofakeit.UUID(),
+			ClientID:      gofakeit.UUID(),
+			OccurredAt:    time.Now().Format(time.RFC3339),
+			Body:          json.RawMessage(body),
+		}
+
+		repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+			return e.Status == "processed"
+		})).Return(nil)
+
+		msg := createMessage(header)
+		msg.MessageAttributes = map[string]string{"content-type": "application/x-protobuf"}
+
+		err = processor.Process(context.Background(), msg)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("avro body that is not a base64 string", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		processor := app.NewProcessor(repo)
+
+		validBodyBytes, _ := generateValidBody()
+
+		header := app.MessageHeader{
+			EventID:       gofakeit.UUID(),
+			EventType:     "user.created",
+			SchemaVersion: "v1",
+			TenantID:      gofakeit.UUID(),
+			ClientID:      gofakeit.UUID(),
+			OccurredAt:    time.Now().Format(time.RFC3339),
+			ContentType:   "avro",
+			Body:          json.RawMessage(validBodyBytes),
+		}
+
+		repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+			return e.Status == "failed"
+		})).Return(nil)
+
+		err := processor.Process(context.Background(), createMessage(header))
+		assert.True(t, ports.IsNonRetriable(err))
+		assert.Contains(t, err.Error(), "must be a base64 string")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("upcasts to the latest version and keeps the original", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		assert.NoError(t, registry.Register("user.created", "v2", func() events.Schema { return &userCreatedV2{} }))
+		assert.NoError(t, registry.RegisterUpcaster("user.created", "v1", "v2", func(s events.Schema) (events.Schema, error) {
+			v1 := s.(*events.UserCreatedV1)
+			return &userCreatedV2{UserCreatedV1: *v1, Locale: "en-US"}, nil
+		}))
+
+		repo := new(MockEventRepository)
+		processor := app.NewProcessorWithRegistry(repo, registry)
+
+		validBodyBytes, _ := generateValidBody()
+		header := app.MessageHeader{
+			EventID:       gofakeit.UUID(),
+			EventType:     "user.created",
+			SchemaVersion: "v1",
+			TenantID:      gofakeit.UUID(),
+			ClientID:      gofakeit.UUID(),
+			OccurredAt:    time.Now().Format(time.RFC3339),
+			Body:          json.RawMessage(validBodyBytes),
+		}
+
+		repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+			var upcast map[string]any
+			_ = json.Unmarshal([]byte(e.UpcastBody), &upcast)
+
+			return e.Status == "processed" &&
+				e.SchemaVersion == "v1" &&
+				e.Body == string(validBodyBytes) &&
+				e.UpcastVersion == "v2" &&
+				upcast["locale"] == "en-US"
+		})).Return(nil)
+
+		err := processor.Process(context.Background(), createMessage(header))
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("stores unknown fields as warnings", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		processor := app.NewProcessor(repo)
+
+		_, bodyMap := generateValidBody()
+		bodyMap["nmae"] = gofakeit.Name()
+		body, _ := json.Marshal(bodyMap)
+
+		header := app.MessageHeader{
+			EventID:       gofakeit.UUID(),
+			EventType:     "user.created",
+			SchemaVersion: "v1",
+			TenantID:      gofakeit.UUID(),
+			ClientID:      gofakeit.UUID(),
+			OccurredAt:    time.Now().Format(time.RFC3339),
+			Body:          json.RawMessage(body),
+		}
+
+		repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+			return e.Status == "processed" &&
+				assert.ObjectsAreEqual([]events.ValidationIssue{
+					{Path: "$.nmae", Rule: events.RuleUnknownField, Message: "is not a known field"},
+				}, e.Warnings)
+		})).Return(nil)
+
+		err := processor.Process(context.Background(), createMessage(header))
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
 	t.Run("repository save error", func(t *testing.T) {
 		repo := new(MockEventRepository)
 		processor := app.NewProcessor(repo)
@@ -162,3 +305,235 @@ func TestProcessor_Process(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to save event")
 	})
 }
+
+func TestProcessor_OccurredAt(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	newProcessor := func(repo *MockEventRepository) *app.Processor {
+		return app.NewProcessorWithOptions(repo, app.Options{
+			Now:          func() time.Time { return now },
+			MaxClockSkew: time.Minute,
+			MaxEventAge:  24 * time.Hour,
+		})
+	}
+
+	headerAt := func(occurredAt string) app.MessageHeader {
+		validBodyBytes, _ := generateValidBody()
+		return app.MessageHeader{
+			EventID:       gofakeit.UUID(),
+			EventType:     "user.created",
+			SchemaVersion: "v1",
+			TenantID:      gofakeit.UUID(),
+			ClientID:      gofakeit.UUID(),
+			OccurredAt:    occurredAt,
+			Body:          json.RawMessage(validBodyBytes),
+		}
+	}
+
+	sentAt := func(t time.Time) string {
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+
+	tests := []struct {
+		name              string
+		occurredAt        string
+		attributes        map[string]string
+		messageAttributes map[string]string
+		wantStatus        string
+		wantStored        string
+		wantErr           string
+	}{
+		{
+			name:       "normalizes offsets to UTC",
+			occurredAt: "2024-05-10T08:30:00.5-03:00",
+			wantStatus: "processed",
+			wantStored: "2024-05-10T11:30:00.5Z",
+		},
+		{
+			name:       "accepts clock skew within the limit",
+			occurredAt: "2024-05-10T12:00:59Z",
+			wantStatus: "processed",
+			wantStored: "2024-05-10T12:00:59Z",
+		},
+		{
+			name:       "rejects events too far in the future",
+			occurredAt: "2024-05-10T12:05:00Z",
+			wantStatus: "failed",
+			wantStored: "2024-05-10T12:05:00Z",
+			wantErr:    "more than the allowed clock skew of 1m0s",
+		},
+		{
+			name:       "rejects timestamps that are not RFC 3339",
+			occurredAt: "yesterday",
+			wantStatus: "failed",
+			wantStored: "yesterday",
+			wantErr:    "occurred_at must be an RFC 3339 timestamp",
+		},
+		{
+			name:       "quarantines stale events",
+			occurredAt: "2024-05-09T11:59:59Z",
+			wantStatus: "quarantined",
+			wantStored: "2024-05-09T11:59:59Z",
+		},
+		{
+			name:       "measures age from the SQS sent timestamp on redeliveries",
+			occurredAt: "2024-05-09T11:59:59Z",
+			attributes: map[string]string{"SentTimestamp": sentAt(now.Add(-2 * time.Hour)), "ApproximateReceiveCount": "4"},
+			wantStatus: "processed",
+			wantStored: "2024-05-09T11:59:59Z",
+		},
+		{
+			name:              "falls back to the sent-at attribute",
+			occurredAt:        "2024-05-09T11:59:59Z",
+			messageAttributes: map[string]string{app.SentAtAttribute: sentAt(now.Add(-2 * time.Hour))},
+			wantStatus:        "processed",
+			wantStored:        "2024-05-09T11:59:59Z",
+		},
+		{
+			name:       "measures clock skew from the sent timestamp",
+			occurredAt: "2024-05-10T11:00:00Z",
+			attributes: map[string]string{"SentTimestamp": sentAt(now.Add(-2 * time.Hour))},
+			wantStatus: "failed",
+			wantStored: "2024-05-10T11:00:00Z",
+			wantErr:    "more than the allowed clock skew of 1m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockEventRepository)
+			repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+				return e.Status == tt.wantStatus && e.OccurredAt == tt.wantStored
+			})).Return(nil)
+
+			msg := createMessage(headerAt(tt.occurredAt))
+			msg.Attributes = tt.attributes
+			msg.MessageAttributes = tt.messageAttributes
+
+			err := newProcessor(repo).Process(context.Background(), msg)
+			if tt.wantErr != "" {
+				assert.True(t, ports.IsNonRetriable(err))
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProcessor_QuarantineMetric(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	count := func(key string) int64 {
+		if v, ok := metrics.EventsQuarantined.Get(key).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	unknown := count("unknown")
+
+	repo := new(MockEventRepository)
+	repo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	processor := app.NewProcessorWithOptions(repo, app.Options{
+		Now:          func() time.Time { return now },
+		MaxClockSkew: time.Minute,
+		MaxEventAge:  time.Hour,
+	})
+
+	eventType := "spam." + gofakeit.UUID()
+	err := processor.Process(context.Background(), createMessage(app.MessageHeader{
+		EventID:       gofakeit.UUID(),
+		EventType:     eventType,
+		SchemaVersion: "v1",
+		TenantID:      gofakeit.UUID(),
+		ClientID:      gofakeit.UUID(),
+		OccurredAt:    "2024-05-09T12:00:00Z",
+		Body:          json.RawMessage(`{}`),
+	}))
+
+	assert.NoError(t, err)
+	assert.Nil(t, metrics.EventsQuarantined.Get(eventType))
+	assert.Equal(t, unknown+1, count("unknown"))
+}
+
+func TestProcessor_Lag(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	histogram := func(m *expvar.Map) (count int, sum float64) {
+		var h struct {
+			Count int     `json:"count"`
+			Sum   float64 `json:"sum"`
+		}
+		if v := m.Get("user.created"); v != nil {
+			assert.NoError(t, json.Unmarshal([]byte(v.String()), &h))
+		}
+		return h.Count, h.Sum
+	}
+	ingestCount, ingestSum := histogram(metrics.IngestLag)
+	eventCount, eventSum := histogram(metrics.EventLag)
+
+	repo := new(MockEventRepository)
+	repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+		return e.ProcessedAt == "2024-05-10T12:00:00Z"
+	})).Return(nil)
+	processor := app.NewProcessorWithOptions(repo, app.Options{
+		Now:          func() time.Time { return now },
+		MaxClockSkew: time.Minute,
+	})
+
+	validBodyBytes, _ := generateValidBody()
+	msg := createMessage(app.MessageHeader{
+		EventID:       gofakeit.UUID(),
+		EventType:     "user.created",
+		SchemaVersion: "v1",
+		TenantID:      gofakeit.UUID(),
+		ClientID:      gofakeit.UUID(),
+		OccurredAt:    "2024-05-10T11:59:58Z",
+		Body:          json.RawMessage(validBodyBytes),
+	})
+	msg.MessageAttributes = map[string]string{app.SentAtAttribute: strconv.FormatInt(now.Add(-500*time.Millisecond).UnixMilli(), 10)}
+
+	assert.NoError(t, processor.Process(context.Background(), msg))
+	repo.AssertExpectations(t)
+
+	count, sum := histogram(metrics.IngestLag)
+	assert.Equal(t, ingestCount+1, count)
+	assert.InDelta(t, ingestSum+0.5, sum, 0.001)
+
+	count, sum = histogram(metrics.EventLag)
+	assert.Equal(t, eventCount+1, count)
+	assert.InDelta(t, eventSum+2, sum, 0.001)
+}
+
+func TestProcessor_ProcessedAt(t *testing.T) {
+	start := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	clock := start
+
+	repo := new(MockEventRepository)
+	repo.On("Save", mock.Anything, mock.MatchedBy(func(e models.EventRecord) bool {
+		processedAt, err := time.Parse(time.RFC3339Nano, e.ProcessedAt)
+		return err == nil && processedAt.After(start)
+	})).Return(nil)
+	processor := app.NewProcessorWithOptions(repo, app.Options{
+		Now: func() time.Time {
+			now := clock
+			clock = clock.Add(time.Second)
+			return now
+		},
+		MaxClockSkew: time.Minute,
+	})
+
+	validBodyBytes, _ := generateValidBody()
+	err := processor.Process(context.Background(), createMessage(app.MessageHeader{
+		EventID:       gofakeit.UUID(),
+		EventType:     "user.created",
+		SchemaVersion: "v1",
+		TenantID:      gofakeit.UUID(),
+		ClientID:      gofakeit.UUID(),
+		OccurredAt:    "2024-05-10T11:59:58Z",
+		Body:          json.RawMessage(validBodyBytes),
+	}))
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
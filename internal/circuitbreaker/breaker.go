@@ -0,0 +1,179 @@
+package circuitbreaker
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/metrics"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half-open"
+)
+
+type Options struct {
+	Name             string
+	FailureThreshold int
+	SuccessThreshold int
+	OpenTimeout      time.Duration
+	MaxProbes        int
+}
+
+// Breaker opens after FailureThreshold consecutive failures and rejects calls
+// for OpenTimeout. It then lets up to MaxProbes calls through at a time and
+// closes again after SuccessThreshold of them succeed; any probe failure
+// opens it again.
+type Breaker struct {
+	mu               sync.Mutex
+	name             string
+	state            State
+	failures         int
+	successes        int
+	probes           int
+	openedAt         time.Time
+	failureThreshold int
+	successThreshold int
+	openTimeout      time.Duration
+	maxProbes        int
+	stateVar         *expvar.String
+	now              func() time.Time
+}
+
+func NewBreaker(opts Options) *Breaker {
+	b := &Breaker{
+		name:             opts.Name,
+		state:            StateClosed,
+		failureThreshold: max(1, opts.FailureThreshold),
+		successThreshold: max(1, opts.SuccessThreshold),
+		openTimeout:      opts.OpenTimeout,
+		maxProbes:        max(1, opts.MaxProbes),
+		stateVar:         new(expvar.String),
+		now:              time.Now,
+	}
+	if b.openTimeout <= 0 {
+		b.openTimeout = 30 * time.Second
+	}
+
+	b.stateVar.Set(string(StateClosed))
+	metrics.CircuitBreakerState.Set(b.name, b.stateVar)
+
+	return b
+}
+
+// State reports the current state, moving an expired open breaker to
+// half-open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+	return b.state
+}
+
+func (b *Breaker) IsOpen() bool {
+	return b.State() == StateOpen
+}
+
+// Available reports how many calls Allow would let through right now: none
+// while open, the free probe slots while half-open, and -1, meaning no limit,
+// while closed.
+func (b *Breaker) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	switch b.state {
+	case StateOpen:
+		return 0
+	case StateHalfOpen:
+		return b.maxProbes - b.probes
+	}
+	return -1
+}
+
+// Allow reserves a call. It must be followed by Done with the call's result,
+// or by Release when the call had no result.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	switch b.state {
+	case StateOpen:
+		return ports.ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.maxProbes {
+			return ports.ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+func (b *Breaker) Done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+
+	if err != nil {
+		b.successes = 0
+		b.failures++
+		if b.state == StateHalfOpen || b.failures >= b.failureThreshold {
+			b.transition(StateOpen)
+		}
+		return
+	}
+
+	b.failures = 0
+	if b.state == StateHalfOpen {
+		b.successes++
+		if b.successes >= b.successThreshold {
+			b.transition(StateClosed)
+		}
+	}
+}
+
+// Release frees the probe slot of a call that was given up on, such as a
+// cancelled one, without counting it as a success or a failure.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *Breaker) expire() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.transition(StateHalfOpen)
+	}
+}
+
+func (b *Breaker) transition(state State) {
+	if b.state == state {
+		return
+	}
+
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+
+	b.stateVar.Set(string(state))
+	metrics.CircuitBreakerTransitions.Add(b.name+":"+string(state), 1)
+}
@@ -0,0 +1,162 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/models"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) Save(ctx context.Context, event models.EventRecord) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func newTestBreaker(t *testing.T) (*Breaker, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	breaker := NewBreaker(Options{
+		Name:             t.Name(),
+		FailureThreshold: 3,
+		SuccessThreshold: 2,
+		OpenTimeout:      10 * time.Second,
+		MaxProbes:        1,
+	})
+	breaker.now = func() time.Time { return now }
+	return breaker, &now
+}
+
+func TestBreaker(t *testing.T) {
+	dbErr := errors.New("db error")
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		breaker, _ := newTestBreaker(t)
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, breaker.Allow())
+			breaker.Done(dbErr)
+		}
+		assert.NoError(t, breaker.Allow())
+		breaker.Done(nil)
+		assert.Equal(t, StateClosed, breaker.State())
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, breaker.Allow())
+			breaker.Done(dbErr)
+		}
+		assert.Equal(t, StateOpen, breaker.State())
+		assert.True(t, breaker.IsOpen())
+		assert.ErrorIs(t, breaker.Allow(), ports.ErrCircuitOpen)
+	})
+
+	t.Run("half-opens after the timeout and closes after successful probes", func(t *testing.T) {
+		breaker, now := newTestBreaker(t)
+		for i := 0; i < 3; i++ {
+			_ = breaker.Allow()
+			breaker.Done(dbErr)
+		}
+
+		*now = now.Add(10 * time.Second)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+
+		assert.NoError(t, breaker.Allow())
+		assert.ErrorIs(t, breaker.Allow(), ports.ErrCircuitOpen)
+		breaker.Done(nil)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+
+		assert.NoError(t, breaker.Allow())
+		breaker.Done(nil)
+		assert.Equal(t, StateClosed, breaker.State())
+		assert.Equal(t, "closed", breaker.stateVar.Value())
+	})
+
+	t.Run("reopens when a probe fails", func(t *testing.T) {
+		breaker, now := newTestBreaker(t)
+		for i := 0; i < 3; i++ {
+			_ = breaker.Allow()
+			breaker.Done(dbErr)
+		}
+
+		*now = now.Add(10 * time.Second)
+		assert.NoError(t, breaker.Allow())
+		breaker.Done(dbErr)
+
+		assert.Equal(t, StateOpen, breaker.State())
+		assert.Equal(t, "open", breaker.stateVar.Value())
+	})
+
+	t.Run("reports the calls it would allow", func(t *testing.T) {
+		breaker, now := newTestBreaker(t)
+		assert.Equal(t, -1, breaker.Available())
+
+		for i := 0; i < 3; i++ {
+			_ = breaker.Allow()
+			breaker.Done(dbErr)
+		}
+		assert.Equal(t, 0, breaker.Available())
+
+		*now = now.Add(10 * time.Second)
+		assert.Equal(t, 1, breaker.Available())
+		assert.NoError(t, breaker.Allow())
+		assert.Equal(t, 0, breaker.Available())
+	})
+}
+
+func TestRepository_Save(t *testing.T) {
+	t.Run("rejects saves while open", func(t *testing.T) {
+		breaker, _ := newTestBreaker(t)
+		repo := new(MockEventRepository)
+		repository := NewRepository(repo, breaker)
+
+		repo.On("Save", mock.Anything, mock.Anything).Return(errors.New("db error")).Times(3)
+
+		for i := 0; i < 3; i++ {
+			assert.Error(t, repository.Save(context.Background(), models.EventRecord{}))
+		}
+
+		err := repository.Save(context.Background(), models.EventRecord{})
+		assert.ErrorIs(t, err, ports.ErrCircuitOpen)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("does not count cancelled saves as failures", func(t *testing.T) {
+		breaker, _ := newTestBreaker(t)
+		repo := new(MockEventRepository)
+		repository := NewRepository(repo, breaker)
+
+		repo.On("Save", mock.Anything, mock.Anything).Return(context.Canceled)
+
+		for i := 0; i < 5; i++ {
+			_ = repository.Save(context.Background(), models.EventRecord{})
+		}
+
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+	t.Run("does not count cancelled probes as successes", func(t *testing.T) {
+		breaker, now := newTestBreaker(t)
+		repo := new(MockEventRepository)
+		repository := NewRepository(repo, breaker)
+
+		repo.On("Save", mock.Anything, mock.Anything).Return(errors.New("db error")).Times(3)
+		for i := 0; i < 3; i++ {
+			_ = repository.Save(context.Background(), models.EventRecord{})
+		}
+		*now = now.Add(10 * time.Second)
+
+		repo.On("Save", mock.Anything, mock.Anything).Return(context.Canceled).Times(2)
+		for i := 0; i < 2; i++ {
+			assert.ErrorIs(t, repository.Save(context.Background(), models.EventRecord{}), context.Canceled)
+		}
+
+		assert.Equal(t, StateHalfOpen, breaker.State())
+		assert.NoError(t, breaker.Allow())
+	})
+}
@@ -0,0 +1,36 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/models"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+type Repository struct {
+	repository ports.EventRepository
+	breaker    *Breaker
+}
+
+func NewRepository(repository ports.EventRepository, breaker *Breaker) *Repository {
+	return &Repository{
+		repository: repository,
+		breaker:    breaker,
+	}
+}
+
+func (r *Repository) Save(ctx context.Context, event models.EventRecord) error {
+	if err := r.breaker.Allow(); err != nil {
+		return err
+	}
+
+	err := r.repository.Save(ctx, event)
+	if errors.Is(err, context.Canceled) {
+		r.breaker.Release()
+		return err
+	}
+
+	r.breaker.Done(err)
+	return err
+}
@@ -0,0 +1,96 @@
+package claimcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+)
+
+// The pointer format and attribute names match the Amazon SQS Extended Client
+// Library, so messages offloaded by either side can be read by the other.
+const (
+	PointerClass                 = "software.amazon.payloads.s3.Pointer"
+	AttributeExtendedPayloadSize = "ExtendedPayloadSize"
+	AttributeLegacyPayloadSize   = "SQSLargePayloadSize"
+	MaxMessageSize               = 256 * 1024
+)
+
+type Pointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// Parse reports whether body is a claim-check pointer rather than a payload.
+func Parse(body []byte) (Pointer, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return Pointer{}, false
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(trimmed, &parts); err != nil || len(parts) != 2 {
+		return Pointer{}, false
+	}
+
+	var class string
+	if err := json.Unmarshal(parts[0], &class); err != nil || class != PointerClass {
+		return Pointer{}, false
+	}
+
+	var pointer Pointer
+	if err := json.Unmarshal(parts[1], &pointer); err != nil || pointer.Bucket == "" || pointer.Key == "" {
+		return Pointer{}, false
+	}
+
+	return pointer, true
+}
+
+func Encode(pointer Pointer) ([]byte, error) {
+	return json.Marshal([]any{PointerClass, pointer})
+}
+
+// Resolve returns the payload a pointer body refers to, or body unchanged
+// when it is not a pointer. A payload over maxSize bytes fails with
+// ports.ErrBlobTooLarge.
+func Resolve(ctx context.Context, store ports.BlobStore, body []byte, maxSize int64) ([]byte, *Pointer, error) {
+	pointer, ok := Parse(body)
+	if !ok {
+		return body, nil, nil
+	}
+
+	payload, err := store.Get(ctx, pointer.Bucket, pointer.Key, maxSize)
+	if err != nil {
+		return nil, &pointer, fmt.Errorf("failed to resolve payload s3://%s/%s: %w", pointer.Bucket, pointer.Key, err)
+	}
+
+	return payload, &pointer, nil
+}
+
+// Offload stores payloads larger than threshold in bucket and returns the
+// pointer body to send instead, along with the message attributes the
+// extended client sets. Smaller payloads are returned unchanged.
+func Offload(ctx context.Context, store ports.BlobStore, bucket string, payload []byte, threshold int) ([]byte, map[string]string, error) {
+	if threshold <= 0 || threshold > MaxMessageSize {
+		threshold = MaxMessageSize
+	}
+	if len(payload) <= threshold {
+		return payload, nil, nil
+	}
+
+	pointer := Pointer{Bucket: bucket, Key: uuid.New().String()}
+	if err := store.Put(ctx, pointer.Bucket, pointer.Key, payload); err != nil {
+		return nil, nil, fmt.Errorf("failed to offload payload: %w", err)
+	}
+
+	body, err := Encode(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, map[string]string{AttributeExtendedPayloadSize: strconv.Itoa(len(payload))}, nil
+}
@@ -0,0 +1,67 @@
+package claimcheck
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/adapters/fsblob"
+	"github.com/guilherme-daniel-rs/event-processor/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("extended client pointer", func(t *testing.T) {
+		body := `["software.amazon.payloads.s3.Pointer",{"s3BucketName":"payloads","s3Key":"a1b2"}]`
+
+		pointer, ok := Parse([]byte(body))
+
+		assert.True(t, ok)
+		assert.Equal(t, Pointer{Bucket: "payloads", Key: "a1b2"}, pointer)
+	})
+
+	t.Run("ordinary payloads", func(t *testing.T) {
+		for _, body := range []string{
+			`{"event_id":"1"}`,
+			`["software.amazon.payloads.s3.Pointer"]`,
+			`["other.Pointer",{"s3BucketName":"payloads","s3Key":"a1b2"}]`,
+			`["software.amazon.payloads.s3.Pointer",{"s3BucketName":"payloads"}]`,
+			`[1,2]`,
+			``,
+		} {
+			_, ok := Parse([]byte(body))
+			assert.False(t, ok, body)
+		}
+	})
+}
+
+func TestOffload(t *testing.T) {
+	ctx := context.Background()
+	store := fsblob.NewBlobStore(t.TempDir())
+
+	t.Run("keeps small payloads inline", func(t *testing.T) {
+		body, attrs, err := Offload(ctx, store, "payloads", []byte(`{}`), 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(`{}`), body)
+		assert.Nil(t, attrs)
+	})
+
+	t.Run("round trips large payloads", func(t *testing.T) {
+		payload := []byte(`{"data":"` + strings.Repeat("x", MaxMessageSize) + `"}`)
+
+		body, attrs, err := Offload(ctx, store, "payloads", payload, 0)
+		assert.NoError(t, err)
+		assert.Less(t, len(body), 256)
+		assert.Equal(t, map[string]string{AttributeExtendedPayloadSize: strconv.Itoa(len(payload))}, attrs)
+
+		resolved, pointer, err := Resolve(ctx, store, body, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "payloads", pointer.Bucket)
+		assert.Equal(t, payload, resolved)
+
+		_, _, err = Resolve(ctx, store, body, int64(len(payload)-1))
+		assert.ErrorIs(t, err, ports.ErrBlobTooLarge)
+	})
+}
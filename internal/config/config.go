@@ -1,7 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -9,11 +12,18 @@ import (
 var configuration *Config
 
 type Config struct {
-	AppName  string         `mapstructure:"APP_NAME"`
-	Port     int            `mapstructure:"PORT" default:"8080"`
-	AWS      awsConfig      `mapstructure:",squash"`
-	SQS      sqsConfig      `mapstructure:",squash"`
-	DynamoDB dynamoDBConfig `mapstructure:",squash"`
+	AppName    string         `mapstructure:"APP_NAME"`
+	Port       int            `mapstructure:"PORT" default:"8080"`
+	AdminToken string         `mapstructure:"ADMIN_TOKEN"`
+	AWS        awsConfig      `mapstructure:",squash"`
+	SQS        sqsConfig      `mapstructure:",squash"`
+	DynamoDB   dynamoDBConfig `mapstructure:",squash"`
+	Tenant     tenantConfig   `mapstructure:",squash"`
+	Breaker    breakerConfig  `mapstructure:",squash"`
+	Adaptive   adaptiveConfig `mapstructure:",squash"`
+	Blob       blobConfig     `mapstructure:",squash"`
+	Schema     schemaConfig   `mapstructure:",squash"`
+	Event      eventConfig    `mapstructure:",squash"`
 }
 
 type awsConfig struct {
@@ -24,28 +34,149 @@ type awsConfig struct {
 }
 
 type sqsConfig struct {
-	QueueURL    string `mapstructure:"SQS_QUEUE_URL" default:"http://localhost:4566/000000000000/events-main"`
-	MaxMessages int32  `mapstructure:"SQS_MAX_MESSAGES" default:"5"`
-	WaitTimeSec int32  `mapstructure:"SQS_WAIT_TIME_SEC" default:"10"`
-	MaxRetries  int32  `mapstructure:"SQS_MAX_RETRIES" default:"5"`
+	QueueURL        string `mapstructure:"SQS_QUEUE_URL" default:"http://localhost:4566/000000000000/events-main"`
+	MaxMessages     int32  `mapstructure:"SQS_MAX_MESSAGES" default:"5"`
+	WaitTimeSec     int32  `mapstructure:"SQS_WAIT_TIME_SEC" default:"10"`
+	MaxRetries      int32  `mapstructure:"SQS_MAX_RETRIES" default:"5"`
+	FIFO            bool   `mapstructure:"SQS_FIFO" default:"false"`
+	PartitionKey    string `mapstructure:"SQS_PARTITION_KEY"`
+	PartitionShards int    `mapstructure:"SQS_PARTITION_SHARDS" default:"10"`
+	Concurrency     int    `mapstructure:"SQS_CONCURRENCY" default:"0"`
+	QueuesJSON      string `mapstructure:"SQS_QUEUES"`
+	Scheduling      string `mapstructure:"SQS_SCHEDULING"`
+	SchedulerBudget int    `mapstructure:"SQS_SCHEDULER_CONCURRENCY" default:"0"`
+	MaxBodyBytes    int64  `mapstructure:"SQS_MAX_BODY_BYTES" default:"10485760"`
+}
+
+type QueueConfig struct {
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	MaxMessages     int32  `json:"max_messages"`
+	WaitTimeSec     int32  `json:"wait_time_sec"`
+	MaxRetries      int32  `json:"max_retries"`
+	Concurrency     int    `json:"concurrency"`
+	FIFO            bool   `json:"fifo"`
+	PartitionKey    string `json:"partition_key"`
+	PartitionShards int    `json:"partition_shards"`
+	Weight          int    `json:"weight"`
+}
+
+type tenantConfig struct {
+	RateLimit          float64 `mapstructure:"TENANT_RATE_LIMIT" default:"0"`
+	RateBurst          int     `mapstructure:"TENANT_RATE_BURST" default:"0"`
+	RateLimitOverrides string  `mapstructure:"TENANT_RATE_OVERRIDES"`
+}
+
+type breakerConfig struct {
+	Enabled          bool  `mapstructure:"BREAKER_ENABLED" default:"true"`
+	FailureThreshold int   `mapstructure:"BREAKER_FAILURE_THRESHOLD" default:"5"`
+	SuccessThreshold int   `mapstructure:"BREAKER_SUCCESS_THRESHOLD" default:"2"`
+	OpenTimeoutSec   int32 `mapstructure:"BREAKER_OPEN_TIMEOUT_SEC" default:"30"`
+	MaxProbes        int   `mapstructure:"BREAKER_MAX_PROBES" default:"1"`
+}
+
+type adaptiveConfig struct {
+	Enabled          bool    `mapstructure:"ADAPTIVE_CONCURRENCY_ENABLED" default:"false"`
+	Initial          int     `mapstructure:"ADAPTIVE_CONCURRENCY_INITIAL" default:"10"`
+	Min              int     `mapstructure:"ADAPTIVE_CONCURRENCY_MIN" default:"1"`
+	Max              int     `mapstructure:"ADAPTIVE_CONCURRENCY_MAX" default:"200"`
+	BackoffRatio     float64 `mapstructure:"ADAPTIVE_CONCURRENCY_BACKOFF_RATIO" default:"0.9"`
+	LatencyTolerance float64 `mapstructure:"ADAPTIVE_CONCURRENCY_LATENCY_TOLERANCE" default:"2"`
+}
+
+type blobConfig struct {
+	Bucket      string `mapstructure:"BLOB_BUCKET" default:"event-payloads"`
+	LocalDir    string `mapstructure:"BLOB_LOCAL_DIR"`
+	DeleteOnAck bool   `mapstructure:"BLOB_DELETE_ON_ACK" default:"false"`
+	MaxBytes    int64  `mapstructure:"BLOB_MAX_BYTES" default:"10485760"`
+}
+
+type schemaConfig struct {
+	Dir                    string `mapstructure:"SCHEMA_DIR"`
+	Watch                  bool   `mapstructure:"SCHEMA_WATCH" default:"true"`
+	Compatibility          string `mapstructure:"SCHEMA_COMPATIBILITY" default:"BACKWARD"`
+	CompatibilityOverrides string `mapstructure:"SCHEMA_COMPATIBILITY_OVERRIDES"`
+	Strictness             string `mapstructure:"SCHEMA_STRICTNESS" default:"lenient"`
+	StrictnessOverrides    string `mapstructure:"SCHEMA_STRICTNESS_OVERRIDES"`
+}
+
+type eventConfig struct {
+	MaxClockSkewSec int `mapstructure:"EVENT_MAX_CLOCK_SKEW_SEC" default:"300"`
+	MaxAgeSec       int `mapstructure:"EVENT_MAX_AGE_SEC" default:"0"`
 }
 
 type dynamoDBConfig struct {
 	TableName string `mapstructure:"EVENTS_TABLE" default:"events"`
 }
 
-func setDefaultValues(configStruct reflect.Type) {
+// Queues returns the queues declared in SQS_QUEUES, a JSON array of
+// QueueConfig. Fields left out of an entry fall back to the single-queue
+// SQS_* settings, which also describe the only queue when SQS_QUEUES is unset.
+func (s sqsConfig) Queues() ([]QueueConfig, error) {
+	defaults := QueueConfig{
+		Name:            "default",
+		URL:             s.QueueURL,
+		MaxMessages:     s.MaxMessages,
+		WaitTimeSec:     s.WaitTimeSec,
+		MaxRetries:      s.MaxRetries,
+		Concurrency:     s.Concurrency,
+		FIFO:            s.FIFO,
+		PartitionKey:    s.PartitionKey,
+		PartitionShards: s.PartitionShards,
+	}
+	if s.QueuesJSON == "" {
+		return []QueueConfig{defaults}, nil
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal([]byte(s.QueuesJSON), &entries); err != nil {
+		return nil, fmt.Errorf("invalid SQS_QUEUES: %w", err)
+	}
+
+	queues := make([]QueueConfig, 0, len(entries))
+	names := map[string]bool{}
+	for i, entry := range entries {
+		queue := defaults
+		queue.Name = ""
+		queue.URL = ""
+		if err := json.Unmarshal(entry, &queue); err != nil {
+			return nil, fmt.Errorf("invalid SQS_QUEUES entry %d: %w", i, err)
+		}
+		if queue.URL == "" {
+			return nil, fmt.Errorf("invalid SQS_QUEUES entry %d: url is required", i)
+		}
+		if queue.Name == "" {
+			queue.Name = queue.URL
+		}
+		if names[queue.Name] {
+			return nil, fmt.Errorf("invalid SQS_QUEUES entry %d: duplicate queue name %s", i, queue.Name)
+		}
+		names[queue.Name] = true
+
+		queues = append(queues, queue)
+	}
+
+	return queues, nil
+}
+
+// bindKeys binds every mapstructure key to its environment variable and
+// sets the defaults. viper.Unmarshal only sees keys it knows about, so a key
+// without a default would otherwise never be read from the environment.
+func bindKeys(configStruct reflect.Type) {
 	for i := 0; i < configStruct.NumField(); i++ {
 		field := configStruct.Field(i)
-		configName := field.Tag.Get("mapstructure")
+		configName, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
 		defaultValue := field.Tag.Get("default")
 
+		if configName != "" {
+			_ = viper.BindEnv(configName)
+		}
 		if configName != "" && defaultValue != "" {
 			viper.SetDefault(configName, defaultValue)
 		}
 
 		if field.Type.Kind() == reflect.Struct {
-			setDefaultValues(field.Type)
+			bindKeys(field.Type)
 		}
 	}
 }
@@ -54,7 +185,7 @@ func Load() error {
 	viper.AutomaticEnv()
 
 	configType := reflect.TypeOf(Config{})
-	setDefaultValues(configType)
+	bindKeys(configType)
 
 	configuration, _ = GetConfig()
 
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQSConfig_Queues(t *testing.T) {
+	base := sqsConfig{
+		QueueURL:    "http://localhost:4566/000000000000/events-main",
+		MaxMessages: 5,
+		WaitTimeSec: 10,
+		MaxRetries:  5,
+	}
+
+	t.Run("falls back to the single queue settings", func(t *testing.T) {
+		queues, err := base.Queues()
+		assert.NoError(t, err)
+		assert.Len(t, queues, 1)
+		assert.Equal(t, "default", queues[0].Name)
+		assert.Equal(t, base.QueueURL, queues[0].URL)
+		assert.Equal(t, int32(5), queues[0].MaxMessages)
+	})
+
+	t.Run("parses queue list with per queue overrides", func(t *testing.T) {
+		cfg := base
+		cfg.QueuesJSON = `[
+			{"name": "critical", "url": "http://localhost:4566/000000000000/events-critical", "max_messages": 10, "concurrency": 20},
+			{"name": "bulk", "url": "http://localhost:4566/000000000000/events-bulk", "max_retries": 2}
+		]`
+
+		queues, err := cfg.Queues()
+		assert.NoError(t, err)
+		assert.Len(t, queues, 2)
+
+		assert.Equal(t, "critical", queues[0].Name)
+		assert.Equal(t, int32(10), queues[0].MaxMessages)
+		assert.Equal(t, 20, queues[0].Concurrency)
+		assert.Equal(t, int32(5), queues[0].MaxRetries)
+
+		assert.Equal(t, "bulk", queues[1].Name)
+		assert.Equal(t, int32(5), queues[1].MaxMessages)
+		assert.Equal(t, int32(2), queues[1].MaxRetries)
+		assert.Equal(t, int32(10), queues[1].WaitTimeSec)
+	})
+
+	t.Run("rejects invalid definitions", func(t *testing.T) {
+		tests := map[string]string{
+			"invalid json":   `[{invalid-json}]`,
+			"missing url":    `[{"name": "critical"}]`,
+			"duplicate name": `[{"name": "a", "url": "q1"}, {"name": "a", "url": "q2"}]`,
+		}
+
+		for name, queuesJSON := range tests {
+			t.Run(name, func(t *testing.T) {
+				cfg := base
+				cfg.QueuesJSON = queuesJSON
+				_, err := cfg.Queues()
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		value string
+		got   func(cfg *Config) any
+	}{
+		{name: "admin token", env: "ADMIN_TOKEN", value: "secret", got: func(cfg *Config) any { return cfg.AdminToken }},
+		{name: "partition key", env: "SQS_PARTITION_KEY", value: `tenant_id`, got: func(cfg *Config) any { return cfg.SQS.PartitionKey }},
+		{name: "queue list", env: "SQS_QUEUES", value: `[{"name": "critical", "url": "http://localhost:4566/000000000000/events-critical"}]`, got: func(cfg *Config) any { return cfg.SQS.QueuesJSON }},
+		{name: "scheduling", env: "SQS_SCHEDULING", value: `priority`, got: func(cfg *Config) any { return cfg.SQS.Scheduling }},
+		{name: "tenant rate overrides", env: "TENANT_RATE_OVERRIDES", value: `tenant-1=10:20`, got: func(cfg *Config) any { return cfg.Tenant.RateLimitOverrides }},
+		{name: "local blob dir", env: "BLOB_LOCAL_DIR", value: `/tmp/blobs`, got: func(cfg *Config) any { return cfg.Blob.LocalDir }},
+		{name: "compatibility overrides", env: "SCHEMA_COMPATIBILITY_OVERRIDES", value: `user.created=FULL`, got: func(cfg *Config) any { return cfg.Schema.CompatibilityOverrides }},
+		{name: "strictness overrides", env: "SCHEMA_STRICTNESS_OVERRIDES", value: `payment.processed=strict`, got: func(cfg *Config) any { return cfg.Schema.StrictnessOverrides }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			assert.NoError(t, Load())
+			assert.Equal(t, tt.value, tt.got(Get()))
+		})
+	}
+
+	t.Run("keeps defaults for unset keys", func(t *testing.T) {
+		assert.NoError(t, Load())
+		assert.Equal(t, 8080, Get().Port)
+		assert.Equal(t, "", Get().AdminToken)
+		assert.Equal(t, int64(10485760), Get().Blob.MaxBytes)
+	})
+}
@@ -0,0 +1,173 @@
+package contentencoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	Identity = "identity"
+	Gzip     = "gzip"
+	Zstd     = "zstd"
+	Deflate  = "deflate"
+	Base64   = "base64"
+
+	DefaultMaxSize = 10 * 1024 * 1024
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported content encoding")
+	ErrTooLarge    = errors.New("decoded body exceeds the maximum size")
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decode returns body with the encodings listed in encoding removed, e.g.
+// "gzip" or "base64, zstd". SQS bodies are text, so a compressed body is
+// expected to be base64 encoded even when encoding only names the
+// compression. With no encoding, a body that is not JSON is tried as base64
+// and then sniffed for gzip and zstd headers. The result is never larger
+// than maxSize bytes.
+func Decode(body []byte, encoding string, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	encodings := parse(encoding)
+	if len(encodings) == 0 {
+		return sniff(body, maxSize)
+	}
+
+	data := body
+	for i, enc := range encodings {
+		var err error
+		switch enc {
+		case Identity:
+			continue
+		case Base64:
+			data, err = decodeBase64(data)
+		case Gzip, Zstd, Deflate:
+			if i == 0 && !isBinary(data) {
+				if data, err = decodeBase64(data); err != nil {
+					break
+				}
+			}
+			data, err = decompress(enc, data, maxSize)
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnsupported, enc)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func parse(encoding string) []string {
+	var encodings []string
+	for _, enc := range strings.Split(encoding, ",") {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		switch enc {
+		case "":
+		case "x-gzip":
+			encodings = append(encodings, Gzip)
+		default:
+			encodings = append(encodings, enc)
+		}
+	}
+	return encodings
+}
+
+func sniff(body []byte, maxSize int64) ([]byte, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return body, nil
+	}
+
+	data, err := decodeBase64(trimmed)
+	if err != nil {
+		return body, nil
+	}
+
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return decompress(Gzip, data, maxSize)
+	case bytes.HasPrefix(data, zstdMagic):
+		return decompress(Zstd, data, maxSize)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func isBinary(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic) || bytes.HasPrefix(data, zstdMagic)
+}
+
+func decodeBase64(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded := make([]byte, enc.DecodedLen(len(trimmed)))
+		n, err := enc.Decode(decoded, trimmed)
+		if err == nil {
+			return decoded[:n], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 body")
+}
+
+func decompress(encoding string, data []byte, maxSize int64) ([]byte, error) {
+	var r io.Reader
+	switch encoding {
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case Deflate:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid deflate body: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	out, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return nil, ErrTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s body: %w", encoding, err)
+	}
+	if int64(len(out)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package contentencoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+const payload = `{"event_id":"evt-1","body":{"message":"hello"}}`
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, data []byte) []byte {
+	w, err := zstd.NewWriter(nil)
+	assert.NoError(t, err)
+	return w.EncodeAll(data, nil)
+}
+
+func b64(data []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(data))
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+	}{
+		{"plain json", []byte(payload), ""},
+		{"identity", []byte(payload), "identity"},
+		{"base64 attribute", b64([]byte(payload)), "base64"},
+		{"gzip attribute with base64 body", b64(gzipped(t, []byte(payload))), "gzip"},
+		{"explicit base64 then gzip", b64(gzipped(t, []byte(payload))), "base64, gzip"},
+		{"zstd attribute with base64 body", b64(zstded(t, []byte(payload))), "ZSTD"},
+		{"sniffed base64", b64([]byte(payload)), ""},
+		{"sniffed gzip", b64(gzipped(t, []byte(payload))), ""},
+		{"sniffed zstd", b64(zstded(t, []byte(payload))), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Decode(tt.body, tt.encoding, 0)
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, payload, string(out))
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	t.Run("unsupported encoding", func(t *testing.T) {
+		_, err := Decode([]byte(payload), "br", 0)
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		_, err := Decode(b64([]byte("not gzip")), "gzip", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("leaves unknown text untouched", func(t *testing.T) {
+		out, err := Decode([]byte("not json!"), "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("not json!"), out)
+	})
+
+	t.Run("rejects zip bombs", func(t *testing.T) {
+		bomb := []byte(`{"padding":"` + strings.Repeat("0", 1<<20) + `"}`)
+
+		_, err := Decode(b64(gzipped(t, bomb)), "gzip", 64*1024)
+		assert.ErrorIs(t, err, ErrTooLarge)
+
+		_, err = Decode(b64(zstded(t, bomb)), "", 64*1024)
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+}
@@ -0,0 +1,199 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+// Codec turns an event body in one wire format into a Schema. The binary
+// codecs go through JSON, so every schema keeps a single struct and Validate
+// no matter how its events are published.
+type Codec interface {
+	ContentType() string
+	Decode(data []byte, schema Schema) error
+	Encode(v any) ([]byte, error)
+}
+
+// NormalizeContentType maps the aliases producers use to one of the
+// ContentType constants. An empty content type means JSON.
+func NormalizeContentType(contentType string) string {
+	if contentType == "" {
+		return ContentTypeJSON
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+
+	switch strings.ToLower(contentType) {
+	case "json", "application/json":
+		return ContentTypeJSON
+	case "protobuf", "proto", "application/protobuf", "application/x-protobuf", "application/vnd.google.protobuf":
+		return ContentTypeProtobuf
+	case "avro", "avro/binary", "application/avro", "application/x-avro", "application/vnd.apache.avro+binary":
+		return ContentTypeAvro
+	}
+	return strings.ToLower(contentType)
+}
+
+type JSONCodec struct {
+	DisallowUnknownFields bool
+}
+
+func (JSONCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (c JSONCodec) Decode(data []byte, schema Schema) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(schema); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after JSON body")
+	}
+	return nil
+}
+
+func (JSONCodec) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type ProtobufCodec struct {
+	Descriptor protoreflect.MessageDescriptor
+}
+
+func (c ProtobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (c ProtobufCodec) Decode(data []byte, schema Schema) error {
+	msg := dynamicpb.NewMessage(c.Descriptor)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return err
+	}
+
+	jsonData, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, schema)
+}
+
+func (c ProtobufCodec) Encode(v any) ([]byte, error) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(c.Descriptor)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(jsonData, msg); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+type AvroCodec struct {
+	Schema avro.Schema
+}
+
+func (c AvroCodec) ContentType() string {
+	return ContentTypeAvro
+}
+
+func (c AvroCodec) Decode(data []byte, schema Schema) error {
+	var record map[string]any
+	if err := avro.Unmarshal(c.Schema, data, &record); err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, schema)
+}
+
+func (c AvroCodec) Encode(v any) ([]byte, error) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(jsonData, &record); err != nil {
+		return nil, err
+	}
+
+	if recordSchema, ok := c.Schema.(*avro.RecordSchema); ok {
+		for _, field := range recordSchema.Fields() {
+			number, ok := record[field.Name()].(float64)
+			if !ok {
+				continue
+			}
+			switch field.Type().Type() {
+			case avro.Int:
+				record[field.Name()] = int(number)
+			case avro.Long:
+				record[field.Name()] = int64(number)
+			case avro.Float:
+				record[field.Name()] = float32(number)
+			}
+		}
+	}
+
+	return avro.Marshal(c.Schema, record)
+}
+
+type protoField struct {
+	Number int32
+	Name   string
+	Type   descriptorpb.FieldDescriptorProto_Type
+}
+
+// newProtoDescriptor builds a proto3 message descriptor at runtime, so the
+// built-in events need no generated code. Field names match the JSON tags.
+func newProtoDescriptor(name string, fields ...protoField) protoreflect.MessageDescriptor {
+	message := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	for _, f := range fields {
+		message.Field = append(message.Field, &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(f.Name),
+			Number: proto.Int32(f.Number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   f.Type.Enum(),
+		})
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String(fmt.Sprintf("events/%s.proto", name)),
+		Package:     proto.String("events"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{message},
+	}, nil)
+	if err != nil {
+		panic(fmt.Sprintf("invalid protobuf descriptor %s: %v", name, err))
+	}
+
+	return file.Messages().Get(0)
+}
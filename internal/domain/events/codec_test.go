@@ -0,0 +1,92 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaRegistry_Codecs(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+
+	payloads := map[string]map[string]any{
+		"user.created": {
+			"user_id":  "8f14e45f-ceea-4c3b-9e5a-2f0c1e9b7d21",
+			"email":    "test@example.com",
+			"name":     "Test User",
+			"role":     "admin",
+			"verified": true,
+		},
+		"order.placed": {
+			"order_id":    "ord-1",
+			"user_id":     "3b2f7c9e-5d1a-4e8b-9c6f-0a4d2e7b1c58",
+			"total":       100.5,
+			"items_count": 2,
+			"status":      "CONFIRMED",
+		},
+		"payment.processed": {
+			"payment_id":     "pay-1",
+			"order_id":       "ord-1",
+			"amount":         100.5,
+			"payment_method": "credit_card",
+			"status":         "success",
+		},
+	}
+
+	for _, contentType := range []string{events.ContentTypeJSON, events.ContentTypeProtobuf, events.ContentTypeAvro} {
+		for eventType, payload := range payloads {
+			t.Run(contentType+" "+eventType, func(t *testing.T) {
+				codec, err := registry.Codec(eventType, "v1", contentType)
+				assert.NoError(t, err)
+
+				data, err := codec.Encode(payload)
+				assert.NoError(t, err)
+
+				schema, err := registry.Decode(eventType, "v1", contentType, data)
+				assert.NoError(t, err)
+
+				jsonSchema, err := registry.Unmarshal(eventType, "v1", mustJSON(t, payload))
+				assert.NoError(t, err)
+				assert.Equal(t, jsonSchema, schema)
+			})
+		}
+	}
+
+	t.Run("validates decoded binary payloads", func(t *testing.T) {
+		codec, err := registry.Codec("user.created", "v1", "protobuf")
+		assert.NoError(t, err)
+
+		data, err := codec.Encode(map[string]any{"user_id": "123"})
+		assert.NoError(t, err)
+
+		_, err = registry.Decode("user.created", "v1", "protobuf", data)
+		assert.ErrorContains(t, err, "schema validation failed")
+	})
+
+	t.Run("rejects corrupt avro", func(t *testing.T) {
+		_, err := registry.Decode("user.created", "v1", "avro/binary", []byte{0xff, 0xff, 0xff})
+		assert.ErrorContains(t, err, "failed to unmarshal")
+	})
+
+	t.Run("rejects formats the schema was not registered with", func(t *testing.T) {
+		registry.Register("custom.event", "v1", func() events.Schema { return &events.UserCreatedV1{} })
+
+		_, err := registry.Decode("custom.event", "v1", "application/avro", nil)
+		assert.ErrorContains(t, err, "unsupported content type")
+	})
+}
+
+func TestNormalizeContentType(t *testing.T) {
+	assert.Equal(t, events.ContentTypeJSON, events.NormalizeContentType(""))
+	assert.Equal(t, events.ContentTypeJSON, events.NormalizeContentType("application/json; charset=utf-8"))
+	assert.Equal(t, events.ContentTypeProtobuf, events.NormalizeContentType("application/protobuf"))
+	assert.Equal(t, events.ContentTypeAvro, events.NormalizeContentType("Avro"))
+	assert.Equal(t, "text/csv", events.NormalizeContentType("text/csv"))
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	data, err := events.JSONCodec{}.Encode(v)
+	assert.NoError(t, err)
+	return data
+}
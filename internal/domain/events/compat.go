@@ -0,0 +1,311 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type CompatibilityMode string
+
+const (
+	// CompatibilityBackward means consumers on the new version can read events
+	// written with the previous one.
+	CompatibilityBackward CompatibilityMode = "BACKWARD"
+	// CompatibilityForward means consumers on the previous version can read
+	// events written with the new one.
+	CompatibilityForward CompatibilityMode = "FORWARD"
+	CompatibilityFull    CompatibilityMode = "FULL"
+	CompatibilityNone    CompatibilityMode = "NONE"
+)
+
+func ParseCompatibilityMode(s string) (CompatibilityMode, error) {
+	mode := CompatibilityMode(strings.ToUpper(strings.TrimSpace(s)))
+	switch mode {
+	case CompatibilityBackward, CompatibilityForward, CompatibilityFull, CompatibilityNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown compatibility mode: %s", s)
+}
+
+// ParseCompatibilityOverrides parses per event type modes written as
+// "user.created=FULL,order.placed=NONE".
+func ParseCompatibilityOverrides(s string) (map[string]CompatibilityMode, error) {
+	overrides := map[string]CompatibilityMode{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eventType, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(eventType) == "" {
+			return nil, fmt.Errorf("invalid compatibility override: %s", entry)
+		}
+		mode, err := ParseCompatibilityMode(value)
+		if err != nil {
+			return nil, err
+		}
+		overrides[strings.TrimSpace(eventType)] = mode
+	}
+	return overrides, nil
+}
+
+// Shape is the part of a schema that compatibility is judged on: the JSON
+// types a value may have, whether object fields are required, and the values
+// an enum allows. An empty Types means any value.
+type Shape struct {
+	Types    []string          `json:"types,omitempty"`
+	Fields   map[string]*Shape `json:"fields,omitempty"`
+	Required []string          `json:"required,omitempty"`
+	Enum     []string          `json:"enum,omitempty"`
+	Items    *Shape            `json:"items,omitempty"`
+}
+
+type Incompatibility struct {
+	Path   string
+	Reason string
+}
+
+type CompatibilityError struct {
+	EventType string
+	Version   string
+	Previous  string
+	Mode      CompatibilityMode
+	Issues    []Incompatibility
+}
+
+func (e *CompatibilityError) Error() string {
+	issues := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		issues = append(issues, fmt.Sprintf("%s: %s", issue.Path, issue.Reason))
+	}
+	return fmt.Sprintf("%s %s is not %s compatible with %s: %s", e.EventType, e.Version, e.Mode, e.Previous, strings.Join(issues, "; "))
+}
+
+// CheckCompatibility reports what makes next incompatible with previous
+// under mode.
+func CheckCompatibility(mode CompatibilityMode, previous, next *Shape) []Incompatibility {
+	var issues []Incompatibility
+	if mode == CompatibilityBackward || mode == CompatibilityFull {
+		issues = append(issues, canRead(next, previous, "$", true)...)
+	}
+	if mode == CompatibilityForward || mode == CompatibilityFull {
+		for _, issue := range canRead(previous, next, "$", false) {
+			if !slices.Contains(issues, issue) {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+// canRead reports why a consumer expecting reader could reject a value that
+// was valid under writer. backward tells whether reader is the next version,
+// which only changes how issues are worded.
+func canRead(reader, writer *Shape, path string, backward bool) []Incompatibility {
+	if reader == nil || writer == nil {
+		return nil
+	}
+
+	previous, next := writer, reader
+	if !backward {
+		previous, next = reader, writer
+	}
+
+	var issues []Incompatibility
+	if !typesAccept(reader.Types, writer.Types) {
+		issues = append(issues, Incompatibility{
+			Path:   path,
+			Reason: fmt.Sprintf("type changed from %s to %s", typeList(previous.Types), typeList(next.Types)),
+		})
+	}
+
+	if len(reader.Enum) > 0 {
+		missing := difference(writer.Enum, reader.Enum)
+		switch {
+		case len(writer.Enum) == 0 && backward:
+			issues = append(issues, Incompatibility{Path: path, Reason: "enum added to a field that allowed any value"})
+		case len(writer.Enum) == 0:
+			issues = append(issues, Incompatibility{Path: path, Reason: "enum removed from a field"})
+		case len(missing) > 0 && backward:
+			issues = append(issues, Incompatibility{Path: path, Reason: fmt.Sprintf("enum narrowed, %s no longer allowed", strings.Join(missing, ", "))})
+		case len(missing) > 0:
+			issues = append(issues, Incompatibility{Path: path, Reason: fmt.Sprintf("enum widened, %s unknown to the previous version", strings.Join(missing, ", "))})
+		}
+	}
+
+	for _, name := range reader.Required {
+		if slices.Contains(writer.Required, name) {
+			continue
+		}
+
+		var reason string
+		switch {
+		case writer.Fields[name] == nil && backward:
+			reason = "required field added"
+		case writer.Fields[name] == nil:
+			reason = "required field removed"
+		case backward:
+			reason = "optional field became required"
+		default:
+			reason = "required field became optional"
+		}
+		issues = append(issues, Incompatibility{Path: path + "." + name, Reason: reason})
+	}
+
+	names := make([]string, 0, len(reader.Fields))
+	for name := range reader.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		issues = append(issues, canRead(reader.Fields[name], writer.Fields[name], path+"."+name, backward)...)
+	}
+
+	issues = append(issues, canRead(reader.Items, writer.Items, path+"[]", backward)...)
+
+	return issues
+}
+
+func typesAccept(reader, writer []string) bool {
+	if len(reader) == 0 {
+		return true
+	}
+	if len(writer) == 0 {
+		return false
+	}
+	for _, t := range writer {
+		if slices.Contains(reader, t) || (t == "integer" && slices.Contains(reader, "number")) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func typeList(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, "|")
+}
+
+func difference(a, b []string) []string {
+	var out []string
+	for _, v := range a {
+		if !slices.Contains(b, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// compareVersions orders "v2" before "v10"; versions that are not a number
+// with an optional "v" prefix fall back to string order.
+func compareVersions(a, b string) int {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if errA == nil && errB == nil {
+		return na - nb
+	}
+	return strings.Compare(a, b)
+}
+
+// shapeOfFields derives a Shape from the field descriptors of a Go schema
+// struct, so compatibility is judged on the same contract that Describe and
+// schema-export publish.
+func shapeOfFields(fields []FieldDescriptor) *Shape {
+	shape := &Shape{Types: []string{"object"}, Fields: map[string]*Shape{}}
+	for _, field := range fields {
+		if _, ok := shape.Fields[field.Name]; ok {
+			continue
+		}
+		shape.Fields[field.Name] = shapeOfField(field)
+		if field.Required {
+			shape.Required = append(shape.Required, field.Name)
+		}
+	}
+	sort.Strings(shape.Required)
+	return shape
+}
+
+func shapeOfField(field FieldDescriptor) *Shape {
+	shape := &Shape{}
+	if field.Type == "object" && field.Fields != nil {
+		shape = shapeOfFields(field.Fields)
+	} else if field.Type != "" {
+		shape.Types = []string{field.Type}
+	}
+
+	enum := field.Enum
+	for _, rule := range field.Rules {
+		if name, param, _ := strings.Cut(rule, "="); name == RuleOneOf {
+			enum = strings.Fields(param)
+		}
+	}
+	if len(enum) > 0 {
+		shape.Enum = append([]string(nil), enum...)
+		sort.Strings(shape.Enum)
+	}
+
+	if field.Items != nil {
+		shape.Items = shapeOfField(*field.Items)
+	}
+	return shape
+}
+
+// shapeOfJSONSchema derives a Shape from a JSON Schema document. Keywords it
+// does not understand, like $ref or oneOf, leave the value unconstrained.
+func shapeOfJSONSchema(doc any) *Shape {
+	schema, ok := doc.(map[string]any)
+	if !ok {
+		return &Shape{}
+	}
+
+	shape := &Shape{}
+	switch t := schema["type"].(type) {
+	case string:
+		shape.Types = []string{t}
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				shape.Types = append(shape.Types, s)
+			}
+		}
+		sort.Strings(shape.Types)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		for _, v := range enum {
+			shape.Enum = append(shape.Enum, fmt.Sprint(v))
+		}
+		sort.Strings(shape.Enum)
+	}
+	if c, ok := schema["const"]; ok {
+		shape.Enum = []string{fmt.Sprint(c)}
+	}
+
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		shape.Fields = make(map[string]*Shape, len(properties))
+		for name, property := range properties {
+			shape.Fields[name] = shapeOfJSONSchema(property)
+		}
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, v := range required {
+			if s, ok := v.(string); ok {
+				shape.Required = append(shape.Required, s)
+			}
+		}
+		sort.Strings(shape.Required)
+	}
+
+	if items, ok := schema["items"]; ok {
+		shape.Items = shapeOfJSONSchema(items)
+	}
+
+	return shape
+}
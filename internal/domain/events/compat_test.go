@@ -0,0 +1,199 @@
+package events_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func shape(t *testing.T, doc string) *events.Shape {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "probe.event", "v1", doc)
+
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.LoadDir(dir))
+
+	s, err := registry.Shape("probe.event", "v1")
+	assert.NoError(t, err)
+	return s
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	previous := `{
+		"type": "object",
+		"required": ["id", "amount"],
+		"properties": {
+			"id": {"type": "string"},
+			"amount": {"type": "integer"},
+			"status": {"type": "string", "enum": ["NEW", "PAID", "VOID"]},
+			"note": {"type": "string"}
+		}
+	}`
+
+	tests := []struct {
+		name     string
+		next     string
+		backward []events.Incompatibility
+		forward  []events.Incompatibility
+	}{
+		{
+			name:     "identical",
+			next:     previous,
+			backward: nil,
+			forward:  nil,
+		},
+		{
+			name: "optional field added and integer widened to number",
+			next: `{
+				"type": "object",
+				"required": ["id", "amount"],
+				"properties": {
+					"id": {"type": "string"},
+					"amount": {"type": "number"},
+					"status": {"type": "string", "enum": ["NEW", "PAID", "VOID"]},
+					"note": {"type": "string"},
+					"tags": {"type": "array", "items": {"type": "string"}}
+				}
+			}`,
+			backward: nil,
+			forward:  []events.Incompatibility{{Path: "$.amount", Reason: "type changed from integer to number"}},
+		},
+		{
+			name: "required field removed",
+			next: `{
+				"type": "object",
+				"required": ["id"],
+				"properties": {
+					"id": {"type": "string"},
+					"status": {"type": "string", "enum": ["NEW", "PAID", "VOID"]},
+					"note": {"type": "string"}
+				}
+			}`,
+			backward: nil,
+			forward:  []events.Incompatibility{{Path: "$.amount", Reason: "required field removed"}},
+		},
+		{
+			name: "required field added, type changed and enum narrowed",
+			next: `{
+				"type": "object",
+				"required": ["id", "amount", "customer"],
+				"properties": {
+					"id": {"type": "integer"},
+					"amount": {"type": "integer"},
+					"status": {"type": "string", "enum": ["NEW", "PAID"]},
+					"note": {"type": "string"},
+					"customer": {"type": "string"}
+				}
+			}`,
+			backward: []events.Incompatibility{
+				{Path: "$.customer", Reason: "required field added"},
+				{Path: "$.id", Reason: "type changed from string to integer"},
+				{Path: "$.status", Reason: "enum narrowed, VOID no longer allowed"},
+			},
+			forward: []events.Incompatibility{
+				{Path: "$.id", Reason: "type changed from string to integer"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, next := shape(t, previous), shape(t, tt.next)
+
+			assert.Equal(t, tt.backward, events.CheckCompatibility(events.CompatibilityBackward, prev, next))
+			assert.Equal(t, tt.forward, events.CheckCompatibility(events.CompatibilityForward, prev, next))
+			assert.ElementsMatch(t, union(tt.backward, tt.forward), events.CheckCompatibility(events.CompatibilityFull, prev, next))
+			assert.Empty(t, events.CheckCompatibility(events.CompatibilityNone, prev, next))
+		})
+	}
+}
+
+func union(a, b []events.Incompatibility) []events.Incompatibility {
+	out := append([]events.Incompatibility{}, a...)
+	for _, issue := range b {
+		if !slices.Contains(out, issue) {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+func TestSchemaRegistry_Compatibility(t *testing.T) {
+	t.Run("rejects an incompatible version on load", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+
+		err := registry.LoadDir("testdata/incompatible")
+
+		var compatErr *events.CompatibilityError
+		assert.ErrorAs(t, err, &compatErr)
+		assert.Equal(t, "v2", compatErr.Version)
+		assert.Equal(t, "v1", compatErr.Previous)
+		assert.Len(t, compatErr.Issues, 3)
+	})
+
+	t.Run("accepts it when the event type opts out", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		registry.SetCompatibility("invoice.issued", events.CompatibilityNone)
+
+		assert.NoError(t, registry.LoadDir("testdata/incompatible"))
+	})
+
+	t.Run("rejects an incompatible Go struct version", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		registry.SetCompatibility("", events.CompatibilityFull)
+
+		err := registry.Register("order.placed", "v2", func() events.Schema { return &events.UserCreatedV1{} })
+		assert.ErrorContains(t, err, "order.placed v2 is not FULL compatible with v1")
+
+		_, err = registry.Unmarshal("order.placed", "v2", []byte(`{}`))
+		assert.ErrorContains(t, err, "unknown schema version")
+	})
+}
+
+type contractProbe struct {
+	ID    string `json:"id" validate:"required"`
+	Note  string `json:"note"`
+	Level string `json:"level,omitempty" validate:"omitempty,oneof=low high"`
+}
+
+func (e *contractProbe) Validate() error { return nil }
+
+func TestSchemaRegistry_ShapeMatchesJSONSchema(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.Register("contract.probe", "v1", func() events.Schema { return &contractProbe{} }))
+
+	s, err := registry.Shape("contract.probe", "v1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id"}, s.Required)
+	assert.Equal(t, []string{"high", "low"}, s.Fields["level"].Enum)
+
+	data, err := registry.JSONSchema("contract.probe", "v1")
+	assert.NoError(t, err)
+
+	var doc struct {
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"properties"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, s.Required, doc.Required)
+	assert.ElementsMatch(t, s.Fields["level"].Enum, doc.Properties["level"].Enum)
+}
+
+func TestParseCompatibilityOverrides(t *testing.T) {
+	overrides, err := events.ParseCompatibilityOverrides("user.created=full, order.placed=NONE")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]events.CompatibilityMode{
+		"user.created": events.CompatibilityFull,
+		"order.placed": events.CompatibilityNone,
+	}, overrides)
+
+	_, err = events.ParseCompatibilityOverrides("user.created=SIDEWAYS")
+	assert.Error(t, err)
+}
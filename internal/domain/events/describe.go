@@ -0,0 +1,323 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldDescriptor describes one field of an event body for producers: its
+// JSON type and format, whether it must be sent, the values an enum allows
+// and the validate rules it is checked with.
+type FieldDescriptor struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type,omitempty"`
+	Format   string            `json:"format,omitempty"`
+	Required bool              `json:"required"`
+	Enum     []string          `json:"enum,omitempty"`
+	Rules    []string          `json:"rules,omitempty"`
+	Fields   []FieldDescriptor `json:"fields,omitempty"`
+	Items    *FieldDescriptor  `json:"items,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns the JSON Schema of a version, indented and with sorted
+// keys so the output can be diffed. File schemas come back as loaded; Go
+// schemas are generated from their fields and validate tags.
+func (r *SchemaRegistry) JSONSchema(eventType, version string) ([]byte, error) {
+	reg, err := r.lookup(eventType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := reg.document
+	if doc == nil {
+		schema := objectSchema(reg.fields)
+		schema["$schema"] = jsonSchemaDraft
+		schema["title"] = eventType + " " + version
+		doc = schema
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema for %s %s: %w", eventType, version, err)
+	}
+	return append(data, '\n'), nil
+}
+
+// fieldsOfType describes the fields of a Go schema struct in declaration
+// order, flattening embedded structs like encoding/json does.
+func fieldsOfType(t reflect.Type) []FieldDescriptor {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []FieldDescriptor
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && field.Anonymous && derefType(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, fieldsOfType(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		descriptor := describeType(field.Type)
+		descriptor.Name = name
+		for _, entry := range strings.Split(field.Tag.Get("validate"), ",") {
+			switch entry = strings.TrimSpace(entry); entry {
+			case "", tagOmitEmpty:
+			case tagRequired:
+				descriptor.Required = true
+			default:
+				descriptor.Rules = append(descriptor.Rules, entry)
+				switch entry {
+				case RuleEmail, RuleUUID:
+					descriptor.Format = entry
+				}
+			}
+		}
+		fields = append(fields, descriptor)
+	}
+	return fields
+}
+
+func describeType(t reflect.Type) FieldDescriptor {
+	t = derefType(t)
+
+	switch {
+	case t == timeType:
+		return FieldDescriptor{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return FieldDescriptor{}
+	case t == decimalType:
+		return FieldDescriptor{Type: "number", Format: "decimal"}
+	case t.Implements(enumType):
+		return FieldDescriptor{Type: "string", Enum: reflect.Zero(t).Interface().(Enum).Values()}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return FieldDescriptor{Type: "string"}
+	case reflect.Bool:
+		return FieldDescriptor{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FieldDescriptor{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return FieldDescriptor{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		items := describeType(t.Elem())
+		return FieldDescriptor{Type: "array", Items: &items}
+	case reflect.Map:
+		return FieldDescriptor{Type: "object"}
+	case reflect.Struct:
+		return FieldDescriptor{Type: "object", Fields: fieldsOfType(t)}
+	}
+	return FieldDescriptor{}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldsOfJSONSchema describes the properties of a JSON Schema document,
+// sorted by name.
+func fieldsOfJSONSchema(doc any) []FieldDescriptor {
+	schema, ok := doc.(map[string]any)
+	if !ok {
+		return nil
+	}
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]FieldDescriptor, 0, len(names))
+	for _, name := range names {
+		descriptor := describeJSONSchema(properties[name])
+		descriptor.Name = name
+		descriptor.Required = required[name]
+		fields = append(fields, descriptor)
+	}
+	return fields
+}
+
+func describeJSONSchema(doc any) FieldDescriptor {
+	schema, ok := doc.(map[string]any)
+	if !ok {
+		return FieldDescriptor{}
+	}
+
+	var descriptor FieldDescriptor
+	switch t := schema["type"].(type) {
+	case string:
+		descriptor.Type = t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			types = append(types, fmt.Sprint(v))
+		}
+		descriptor.Type = strings.Join(types, "|")
+	}
+	if format, ok := schema["format"].(string); ok {
+		descriptor.Format = format
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		for _, v := range enum {
+			descriptor.Enum = append(descriptor.Enum, fmt.Sprint(v))
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		descriptor.Fields = fieldsOfJSONSchema(schema)
+	}
+	if items, ok := schema["items"]; ok {
+		described := describeJSONSchema(items)
+		descriptor.Items = &described
+	}
+	return descriptor
+}
+
+func objectSchema(fields []FieldDescriptor) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, field := range fields {
+		properties[field.Name] = propertySchema(field)
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// propertySchema turns a field into JSON Schema, mapping the built-in
+// validate rules to keywords. Custom rules have no JSON Schema equivalent
+// and are listed under x-rules.
+func propertySchema(field FieldDescriptor) map[string]any {
+	var schema map[string]any
+	if field.Type == "object" && field.Fields != nil {
+		schema = objectSchema(field.Fields)
+	} else {
+		schema = map[string]any{}
+		if field.Type != "" {
+			schema["type"] = field.Type
+		}
+	}
+
+	switch field.Format {
+	case "email", "uuid", "date-time":
+		schema["format"] = field.Format
+	}
+	if len(field.Enum) > 0 {
+		schema["enum"] = field.Enum
+	}
+	if field.Items != nil {
+		schema["items"] = propertySchema(*field.Items)
+	}
+
+	var custom []string
+	for _, rule := range field.Rules {
+		if !applyRule(schema, field.Type, rule) {
+			custom = append(custom, rule)
+		}
+	}
+	if len(custom) > 0 {
+		schema["x-rules"] = custom
+	}
+	return schema
+}
+
+func applyRule(schema map[string]any, fieldType, rule string) bool {
+	name, param, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case RuleEmail, RuleUUID:
+		return true
+	case RuleEnum:
+		return schema["enum"] != nil
+	case RuleOneOf:
+		schema["enum"] = strings.Fields(param)
+		return true
+	case RuleISO4217:
+		schema["pattern"] = "^[A-Z]{3}$"
+		return true
+	case RuleID:
+		prefixes := strings.Fields(param)
+		for i, prefix := range prefixes {
+			prefixes[i] = regexp.QuoteMeta(prefix)
+		}
+		schema["pattern"] = fmt.Sprintf("^(%s-[A-Za-z0-9][A-Za-z0-9_-]{0,63}|%s)$",
+			"("+strings.Join(prefixes, "|")+")", strings.Trim(uuidPattern.String(), "^$"))
+		return true
+	case RuleGreaterThan, RuleMin, RuleMax:
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+
+		var keyword string
+		switch fieldType {
+		case "number", "integer":
+			keyword = map[string]string{RuleGreaterThan: "exclusiveMinimum", RuleMin: "minimum", RuleMax: "maximum"}[name]
+			schema[keyword] = bound
+			return true
+		case "string":
+			keyword = map[string]string{RuleGreaterThan: "minLength", RuleMin: "minLength", RuleMax: "maxLength"}[name]
+		case "array":
+			keyword = map[string]string{RuleGreaterThan: "minItems", RuleMin: "minItems", RuleMax: "maxItems"}[name]
+		default:
+			return false
+		}
+		if name == RuleGreaterThan {
+			bound++
+		}
+		schema[keyword] = int(bound)
+		return true
+	}
+	return false
+}
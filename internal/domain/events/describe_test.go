@@ -0,0 +1,97 @@
+package events_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaRegistry_Fields(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+
+	var payment events.SchemaInfo
+	for _, info := range registry.Schemas() {
+		if info.EventType == "payment.processed" && info.Version == "v1" {
+			payment = info
+		}
+	}
+
+	assert.Equal(t, []events.FieldDescriptor{
+		{Name: "payment_id", Type: "string", Required: true, Rules: []string{"id=pay"}},
+		{Name: "order_id", Type: "string", Required: true, Rules: []string{"id=ord order"}},
+		{Name: "amount", Type: "number", Format: "decimal", Required: true, Rules: []string{"gt=0"}},
+		{Name: "currency", Type: "string", Rules: []string{"iso4217"}},
+		{Name: "payment_method", Type: "string", Required: true, Enum: []string{"CREDIT_CARD", "DEBIT_CARD", "PIX", "BOLETO", "BANK_TRANSFER"}, Rules: []string{"enum"}},
+		{Name: "status", Type: "string", Required: true, Enum: []string{"PENDING", "SUCCESS", "FAILED", "REFUNDED"}, Rules: []string{"enum"}},
+	}, payment.Fields)
+}
+
+func TestSchemaRegistry_JSONSchema(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.LoadDir("testdata/schemas"))
+
+	t.Run("generates a schema that accepts what the struct accepts", func(t *testing.T) {
+		data, err := registry.JSONSchema("payment.processed", "v1")
+		assert.NoError(t, err)
+
+		again, err := registry.JSONSchema("payment.processed", "v1")
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), string(again))
+
+		var doc map[string]any
+		assert.NoError(t, json.Unmarshal(data, &doc))
+		properties := doc["properties"].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "number", "exclusiveMinimum": 0.0}, properties["amount"])
+		assert.Equal(t, []any{"amount", "order_id", "payment_id", "payment_method", "status"}, doc["required"])
+
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "payment.export"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "payment.export", "v1.json"), data, 0o644))
+
+		exported := events.NewSchemaRegistry()
+		assert.NoError(t, exported.LoadDir(dir))
+		_, err = exported.Unmarshal("payment.export", "v1", mustJSON(t, map[string]any{
+			"payment_id":     "pay-1",
+			"order_id":       "ord-1",
+			"amount":         100.5,
+			"currency":       "BRL",
+			"payment_method": "PIX",
+			"status":         "SUCCESS",
+		}))
+		assert.NoError(t, err)
+		_, err = exported.Unmarshal("payment.export", "v1", mustJSON(t, map[string]any{
+			"payment_id":     "payment 1",
+			"order_id":       "ord-1",
+			"amount":         0,
+			"payment_method": "PIX",
+			"status":         "SUCCESS",
+		}))
+		assert.Equal(t, []string{"$.amount", "$.payment_id"}, paths(issues(t, err)))
+	})
+
+	t.Run("returns file schemas as loaded", func(t *testing.T) {
+		data, err := registry.JSONSchema("invoice.issued", "v1")
+		assert.NoError(t, err)
+
+		original, err := os.ReadFile("testdata/schemas/invoice.issued/v1.json")
+		assert.NoError(t, err)
+		assert.JSONEq(t, string(original), string(data))
+	})
+
+	t.Run("fails for unknown versions", func(t *testing.T) {
+		_, err := registry.JSONSchema("payment.processed", "v9")
+		assert.Error(t, err)
+	})
+}
+
+func paths(issues []events.ValidationIssue) []string {
+	var out []string
+	for _, issue := range issues {
+		out = append(out, issue.Path)
+	}
+	return out
+}
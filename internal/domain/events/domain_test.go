@@ -0,0 +1,163 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func validPayment() map[string]any {
+	return map[string]any{
+		"payment_id":     "pay-1",
+		"order_id":       "ord-1",
+		"amount":         100.5,
+		"currency":       "BRL",
+		"payment_method": "pix",
+		"status":         "success",
+	}
+}
+
+func decodePayment(t *testing.T, change func(map[string]any)) (*events.PaymentProcessedV1, []events.ValidationIssue) {
+	t.Helper()
+	payload := validPayment()
+	change(payload)
+
+	schema, err := events.NewSchemaRegistry().Unmarshal("payment.processed", "v1", mustJSON(t, payload))
+	if err != nil {
+		return nil, issues(t, err)
+	}
+	return schema.(*events.PaymentProcessedV1), nil
+}
+
+func TestPaymentProcessedV1_Domain(t *testing.T) {
+	t.Run("normalizes enum case", func(t *testing.T) {
+		payment, issues := decodePayment(t, func(map[string]any) {})
+		assert.Empty(t, issues)
+		assert.Equal(t, events.PaymentMethodPix, payment.PaymentMethod)
+		assert.Equal(t, events.PaymentStatusSuccess, payment.Status)
+		assert.Equal(t, events.Decimal("100.5"), payment.Amount)
+	})
+
+	tests := []struct {
+		name   string
+		change func(map[string]any)
+		want   events.ValidationIssue
+	}{
+		{
+			name:   "unknown payment method",
+			change: func(p map[string]any) { p["payment_method"] = "cash" },
+			want:   events.ValidationIssue{Path: "$.payment_method", Rule: events.RuleEnum, Message: "must be one of CREDIT_CARD, DEBIT_CARD, PIX, BOLETO, BANK_TRANSFER"},
+		},
+		{
+			name:   "unknown status",
+			change: func(p map[string]any) { p["status"] = "done" },
+			want:   events.ValidationIssue{Path: "$.status", Rule: events.RuleEnum, Message: "must be one of PENDING, SUCCESS, FAILED, REFUNDED"},
+		},
+		{
+			name:   "sub-cent amount",
+			change: func(p map[string]any) { p["amount"] = 0.0000001 },
+			want:   events.ValidationIssue{Path: "$.amount", Rule: events.RuleMoneyScale, Message: "BRL amounts allow at most 2 decimal places"},
+		},
+		{
+			name:   "fractional yen",
+			change: func(p map[string]any) { p["currency"] = "JPY" },
+			want:   events.ValidationIssue{Path: "$.amount", Rule: events.RuleMoneyScale, Message: "JPY amounts allow at most 0 decimal places"},
+		},
+		{
+			name:   "unknown currency",
+			change: func(p map[string]any) { p["currency"] = "XYZ" },
+			want:   events.ValidationIssue{Path: "$.currency", Rule: events.RuleISO4217, Message: "must be an ISO 4217 currency code"},
+		},
+		{
+			name:   "malformed payment id",
+			change: func(p map[string]any) { p["payment_id"] = "payment 1" },
+			want:   events.ValidationIssue{Path: "$.payment_id", Rule: events.RuleID, Message: "must be a UUID or an ID like pay-123"},
+		},
+		{
+			name:   "order id with another prefix",
+			change: func(p map[string]any) { p["order_id"] = "pay-1" },
+			want:   events.ValidationIssue{Path: "$.order_id", Rule: events.RuleID, Message: "must be a UUID or an ID like ord-123"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues := decodePayment(t, tt.change)
+			assert.Equal(t, []events.ValidationIssue{tt.want}, issues)
+		})
+	}
+
+	t.Run("three decimal places are fine for dinars", func(t *testing.T) {
+		_, issues := decodePayment(t, func(p map[string]any) {
+			p["amount"] = 12.345
+			p["currency"] = "KWD"
+		})
+		assert.Empty(t, issues)
+	})
+}
+
+func TestDecimal(t *testing.T) {
+	t.Run("parses numbers and strings exactly", func(t *testing.T) {
+		var amounts struct {
+			Number events.Decimal `json:"number"`
+			String events.Decimal `json:"string"`
+			Exp    events.Decimal `json:"exp"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(`{"number": 100.50, "string": "0.30", "exp": 1.5e3}`), &amounts))
+		assert.Equal(t, events.Decimal("100.5"), amounts.Number)
+		assert.Equal(t, events.Decimal("0.3"), amounts.String)
+		assert.Equal(t, events.Decimal("1500"), amounts.Exp)
+		assert.Equal(t, 1, amounts.Number.Scale())
+
+		data, err := json.Marshal(amounts)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"number": 100.5, "string": 0.3, "exp": 1500}`, string(data))
+	})
+
+	t.Run("rejects what is not a number", func(t *testing.T) {
+		for _, s := range []string{"abc", "1e400", "", "1/3", ".", "1e", "--1", "1.2.3"} {
+			_, err := events.ParseDecimal(s)
+			assert.Error(t, err, s)
+		}
+	})
+
+	t.Run("normalizes literals", func(t *testing.T) {
+		for literal, want := range map[string]events.Decimal{
+			"-0.00": "0", "007.10": "7.1", "1.5e-3": "0.0015", "-2E+2": "-200", "1e-18": "0.000000000000000001",
+		} {
+			got, err := events.ParseDecimal(literal)
+			assert.NoError(t, err, literal)
+			assert.Equal(t, want, got, literal)
+		}
+	})
+
+	t.Run("rejects huge exponents and scales without expanding them", func(t *testing.T) {
+		start := time.Now()
+		for _, s := range []string{"1e-19", "1e19", "1e-50000", "1e-200000", "1e-999999999", "1e99999999999"} {
+			_, err := events.ParseDecimal(s)
+			assert.Error(t, err, s)
+		}
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+		var order struct {
+			Total events.Decimal `json:"total"`
+		}
+		assert.Error(t, json.Unmarshal([]byte(`{"total": "1e-200000"}`), &order))
+	})
+}
+
+func TestEmailRule(t *testing.T) {
+	type contact struct {
+		Email string `json:"email" validate:"email"`
+	}
+
+	for _, email := range []string{"ana@example.com", "first.last+tag@mail.example.co"} {
+		assert.NoError(t, events.ValidateStruct(&contact{Email: email}), email)
+	}
+	for _, email := range []string{"ana", "ana@localhost", "Ana <ana@example.com>", "ana@example..com", "ana@@example.com"} {
+		assert.Error(t, events.ValidateStruct(&contact{Email: email}), email)
+	}
+}
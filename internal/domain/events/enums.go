@@ -0,0 +1,108 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Enum is implemented by string types that only allow a fixed set of
+// values. The enum validate rule checks them, and compatibility checks see
+// their values.
+type Enum interface {
+	Valid() bool
+	Values() []string
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusConfirmed OrderStatus = "CONFIRMED"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+)
+
+var orderStatuses = enumSet[OrderStatus]{
+	OrderStatusPending, OrderStatusConfirmed, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled,
+}
+
+func (s OrderStatus) Valid() bool                   { return orderStatuses.has(s) }
+func (s OrderStatus) Values() []string              { return orderStatuses.strings() }
+func (s *OrderStatus) UnmarshalJSON(b []byte) error { return orderStatuses.unmarshal(b, s) }
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending  PaymentStatus = "PENDING"
+	PaymentStatusSuccess  PaymentStatus = "SUCCESS"
+	PaymentStatusFailed   PaymentStatus = "FAILED"
+	PaymentStatusRefunded PaymentStatus = "REFUNDED"
+)
+
+var paymentStatuses = enumSet[PaymentStatus]{
+	PaymentStatusPending, PaymentStatusSuccess, PaymentStatusFailed, PaymentStatusRefunded,
+}
+
+func (s PaymentStatus) Valid() bool                   { return paymentStatuses.has(s) }
+func (s PaymentStatus) Values() []string              { return paymentStatuses.strings() }
+func (s *PaymentStatus) UnmarshalJSON(b []byte) error { return paymentStatuses.unmarshal(b, s) }
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCreditCard   PaymentMethod = "CREDIT_CARD"
+	PaymentMethodDebitCard    PaymentMethod = "DEBIT_CARD"
+	PaymentMethodPix          PaymentMethod = "PIX"
+	PaymentMethodBoleto       PaymentMethod = "BOLETO"
+	PaymentMethodBankTransfer PaymentMethod = "BANK_TRANSFER"
+)
+
+var paymentMethods = enumSet[PaymentMethod]{
+	PaymentMethodCreditCard, PaymentMethodDebitCard, PaymentMethodPix, PaymentMethodBoleto, PaymentMethodBankTransfer,
+}
+
+func (m PaymentMethod) Valid() bool                   { return paymentMethods.has(m) }
+func (m PaymentMethod) Values() []string              { return paymentMethods.strings() }
+func (m *PaymentMethod) UnmarshalJSON(b []byte) error { return paymentMethods.unmarshal(b, m) }
+
+type enumSet[T ~string] []T
+
+func (s enumSet[T]) has(v T) bool {
+	for _, allowed := range s {
+		if v == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (s enumSet[T]) strings() []string {
+	values := make([]string, len(s))
+	for i, v := range s {
+		values[i] = string(v)
+	}
+	return values
+}
+
+// unmarshal matches values case-insensitively, so "credit_card" decodes as
+// CREDIT_CARD. Values outside the set are kept as sent for validation to
+// report.
+func (s enumSet[T]) unmarshal(data []byte, v *T) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for _, allowed := range s {
+		if strings.EqualFold(raw, string(allowed)) {
+			*v = allowed
+			return nil
+		}
+	}
+	*v = T(raw)
+	return nil
+}
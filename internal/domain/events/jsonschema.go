@@ -0,0 +1,234 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+// DynamicEvent is the Schema for event types defined by a JSON Schema
+// document rather than a Go struct. It keeps the decoded body as generic
+// JSON values.
+type DynamicEvent struct {
+	Value  any
+	schema *jsonschema.Schema
+}
+
+func (e *DynamicEvent) UnmarshalJSON(data []byte) error {
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	e.Value = value
+	return nil
+}
+
+func (e *DynamicEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Value)
+}
+
+func (e *DynamicEvent) Validate() error {
+	err := e.schema.Validate(e.Value)
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	return validationError(validationErr)
+}
+
+// validationError flattens a JSON Schema validation error into its leaf
+// violations, with instance locations as JSON paths such as
+// "$.items[0].sku" and the failing keyword as the rule.
+func validationError(err *jsonschema.ValidationError) error {
+	var out ValidationError
+	for _, unit := range err.BasicOutput().Errors {
+		if unit.Error == nil || isGroup(unit.Error.Kind) {
+			continue
+		}
+		out.Add(pointerToPath(unit.InstanceLocation), keyword(unit.Error.Kind), unit.Error.String())
+	}
+	if len(out.Issues) == 0 {
+		out.Add("$", "schema", err.Error())
+	}
+
+	return out.Err()
+}
+
+func keyword(k jsonschema.ErrorKind) string {
+	path := k.KeywordPath()
+	if len(path) == 0 {
+		return "schema"
+	}
+	return path[len(path)-1]
+}
+
+func isGroup(k jsonschema.ErrorKind) bool {
+	switch k.(type) {
+	case *kind.Group, *kind.Schema, *kind.Reference:
+		return true
+	}
+	return false
+}
+
+func pointerToPath(pointer string) string {
+	if pointer == "" {
+		return "$"
+	}
+
+	var path strings.Builder
+	path.WriteString("$")
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if isIndex(token) {
+			fmt.Fprintf(&path, "[%s]", token)
+			continue
+		}
+		path.WriteString(".")
+		path.WriteString(token)
+	}
+	return path.String()
+}
+
+func isIndex(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadDir loads every JSON Schema document under dir, laid out as
+// <dir>/<event type>/<version>.json, e.g. schemas/invoice.issued/v1.json.
+// Documents default to draft 2020-12 and format keywords are asserted. The
+// loaded set replaces the one from any previous load only if every document
+// compiles, so a bad file leaves the registry as it was.
+func (r *SchemaRegistry) LoadDir(dir string) error {
+	schemas, err := compileDir(dir)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]map[string]registration)
+	for _, s := range schemas {
+		compiled := s.schema
+		reg := newRegistration(func() Schema { return &DynamicEvent{schema: compiled} }, s.path)
+		reg.shape = shapeOfJSONSchema(s.doc)
+		reg.fields = fieldsOfJSONSchema(s.doc)
+		reg.document = s.doc
+		addRegistration(files, s.eventType, s.version, reg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range schemas {
+		if _, ok := r.schemas[s.eventType][s.version]; ok {
+			return fmt.Errorf("schema %s/%s from %s is already registered", s.eventType, s.version, s.path)
+		}
+	}
+
+	previous := r.files
+	r.files = files
+	for eventType := range files {
+		if err := r.checkVersions(eventType, r.versions(eventType)); err != nil {
+			r.files = previous
+			return err
+		}
+	}
+
+	return nil
+}
+
+type fileSchema struct {
+	eventType string
+	version   string
+	path      string
+	doc       any
+	schema    *jsonschema.Schema
+}
+
+func compileDir(dir string) ([]fileSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.AssertFormat()
+
+	var schemas []fileSchema
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 2 {
+			return fmt.Errorf("schema file %s must be at <event type>/<version>.json", path)
+		}
+
+		doc, compiled, err := compileFile(compiler, path)
+		if err != nil {
+			return err
+		}
+
+		schemas = append(schemas, fileSchema{
+			eventType: parts[0],
+			version:   strings.TrimSuffix(parts[1], ".json"),
+			path:      path,
+			doc:       doc,
+			schema:    compiled,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+func compileFile(compiler *jsonschema.Compiler, path string) (any, *jsonschema.Schema, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	doc, err := jsonschema.UnmarshalJSON(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	url := "file://" + filepath.ToSlash(abs)
+
+	if err := compiler.AddResource(url, doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+	}
+
+	return doc, compiled, nil
+}
@@ -0,0 +1,120 @@
+package events_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaRegistry_LoadDir(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.LoadDir("testdata/schemas"))
+
+	t.Run("accepts a valid body", func(t *testing.T) {
+		data := []byte(`{
+			"invoice_id": "inv-1",
+			"customer_email": "billing@example.com",
+			"amount": 120.5,
+			"currency": "BRL",
+			"lines": [{"sku": "sku-1", "quantity": 2}]
+		}`)
+
+		schema, err := registry.Unmarshal("invoice.issued", "v1", data)
+		assert.NoError(t, err)
+
+		event, ok := schema.(*events.DynamicEvent)
+		assert.True(t, ok)
+		assert.Equal(t, "inv-1", event.Value.(map[string]any)["invoice_id"])
+	})
+
+	t.Run("reports every violation with its field path", func(t *testing.T) {
+		data := []byte(`{
+			"customer_email": "not-an-email",
+			"amount": 0,
+			"currency": "GBP",
+			"lines": [{"sku": "sku-1", "quantity": 0}, {"quantity": 1}]
+		}`)
+
+		_, err := registry.Unmarshal("invoice.issued", "v1", data)
+		assert.ErrorContains(t, err, "schema validation failed")
+
+		var validationErr *events.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+
+		paths := make([]string, 0, len(validationErr.Issues))
+		for _, issue := range validationErr.Issues {
+			paths = append(paths, issue.Path)
+		}
+		assert.Equal(t, []string{
+			"$",
+			"$.amount",
+			"$.currency",
+			"$.customer_email",
+			"$.lines[0].quantity",
+			"$.lines[1]",
+		}, paths)
+		assert.Equal(t, "minimum", validationErr.Issues[4].Rule)
+	})
+
+	t.Run("keeps Go struct schemas working", func(t *testing.T) {
+		_, err := registry.Unmarshal("user.created", "v1", []byte(`{"user_id": "123"}`))
+		assert.ErrorContains(t, err, "$.email: is required")
+	})
+
+	t.Run("loads every version", func(t *testing.T) {
+		data := []byte(`{"invoice_id": "inv-1", "customer_email": "a@example.com", "amount": 1, "currency": "USD", "due_date": "2024-02-30"}`)
+
+		_, err := registry.Unmarshal("invoice.issued", "v2", data)
+		assert.ErrorContains(t, err, "$.due_date")
+	})
+
+	t.Run("rejects a directory with an invalid schema", func(t *testing.T) {
+		err := events.NewSchemaRegistry().LoadDir("testdata/invalid")
+		assert.ErrorContains(t, err, "invalid schema file")
+	})
+
+	t.Run("rejects schemas that are registered in code", func(t *testing.T) {
+		err := events.NewSchemaRegistry().LoadDir("testdata/conflict")
+		assert.ErrorContains(t, err, "already registered")
+	})
+
+	t.Run("keeps the previous set when a reload fails", func(t *testing.T) {
+		assert.Error(t, registry.LoadDir("testdata/invalid"))
+
+		_, err := registry.Unmarshal("invoice.issued", "v2", []byte(`{"invoice_id": "inv-1", "customer_email": "a@example.com", "amount": 1, "currency": "USD"}`))
+		assert.NoError(t, err)
+	})
+}
+
+func TestSchemaRegistry_Schemas(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.LoadDir("testdata/schemas"))
+
+	infos := registry.Schemas()
+
+	assert.Len(t, infos, 5)
+	assert.Equal(t, events.SchemaInfo{
+		EventType:    "invoice.issued",
+		Version:      "v1",
+		ContentTypes: []string{events.ContentTypeJSON},
+		Source:       filepath.Join("testdata", "schemas", "invoice.issued", "v1.json"),
+		Fields: []events.FieldDescriptor{
+			{Name: "amount", Type: "number", Required: true},
+			{Name: "currency", Type: "string", Enum: []string{"BRL", "EUR", "USD"}},
+			{Name: "customer_email", Type: "string", Format: "email", Required: true},
+			{Name: "invoice_id", Type: "string", Required: true},
+			{Name: "lines", Type: "array", Required: true, Items: &events.FieldDescriptor{
+				Type: "object",
+				Fields: []events.FieldDescriptor{
+					{Name: "quantity", Type: "integer", Required: true},
+					{Name: "sku", Type: "string", Required: true},
+				},
+			}},
+		},
+	}, infos[0])
+	assert.Equal(t, "order.placed", infos[2].EventType)
+	assert.Equal(t, "go", infos[2].Source)
+	assert.Equal(t, []string{events.ContentTypeAvro, events.ContentTypeJSON, events.ContentTypeProtobuf}, infos[2].ContentTypes)
+}
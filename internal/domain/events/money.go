@@ -0,0 +1,187 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact base-10 number for monetary amounts. It travels as a
+// JSON number, or a string, and never goes through float64, so 0.1 + 0.2
+// stays 0.3. The value is kept in its shortest form: 100.50 becomes 100.5.
+type Decimal string
+
+var decimalType = reflect.TypeOf(Decimal(""))
+
+// MaxDecimalDigits bounds both the integer digits and the decimal places
+// of a Decimal, far beyond any amount but small enough that no literal,
+// however large its exponent, costs more than linear work.
+const MaxDecimalDigits = 18
+
+func ParseDecimal(s string) (Decimal, error) {
+	neg, digits, exp, err := decimalParts(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid decimal %q", s)
+	}
+	if digits == "" {
+		return "0", nil
+	}
+
+	scale := max(0, -exp)
+	if scale > MaxDecimalDigits || len(digits)+exp > MaxDecimalDigits {
+		return "", fmt.Errorf("decimal %q exceeds %d integer digits or decimal places", s, MaxDecimalDigits)
+	}
+
+	var out strings.Builder
+	if neg {
+		out.WriteByte('-')
+	}
+	switch {
+	case exp >= 0:
+		out.WriteString(digits)
+		out.WriteString(strings.Repeat("0", exp))
+	case len(digits) > scale:
+		out.WriteString(digits[:len(digits)-scale])
+		out.WriteByte('.')
+		out.WriteString(digits[len(digits)-scale:])
+	default:
+		out.WriteString("0.")
+		out.WriteString(strings.Repeat("0", scale-len(digits)))
+		out.WriteString(digits)
+	}
+	return Decimal(out.String()), nil
+}
+
+// maxExponentDigits rejects exponents that could not be stored in an int
+// before they are converted.
+const maxExponentDigits = 9
+
+// decimalParts splits a decimal literal, such as "-12.50" or "1.5e3", into
+// its sign, its significant digits with no leading or trailing zeros, and
+// the power of ten they are multiplied by. Zero has no digits.
+func decimalParts(s string) (neg bool, digits string, exp int, err error) {
+	invalid := fmt.Errorf("invalid decimal literal")
+
+	mantissa, exponent, hasExp := strings.Cut(strings.ToLower(s), "e")
+	if hasExp {
+		unsigned := strings.TrimLeft(exponent, "+-")
+		if len(exponent)-len(unsigned) > 1 || unsigned == "" || len(strings.TrimLeft(unsigned, "0")) > maxExponentDigits {
+			return false, "", 0, invalid
+		}
+		if exp, err = strconv.Atoi(exponent); err != nil {
+			return false, "", 0, invalid
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(mantissa, "-"):
+		neg, mantissa = true, mantissa[1:]
+	case strings.HasPrefix(mantissa, "+"):
+		mantissa = mantissa[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	if whole == "" && fraction == "" {
+		return false, "", 0, invalid
+	}
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return false, "", 0, invalid
+		}
+	}
+
+	digits = strings.TrimLeft(whole+fraction, "0")
+	exp -= len(fraction)
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+	if digits == "" {
+		return false, "", 0, nil
+	}
+	return neg, digits, exp, nil
+}
+
+func (d Decimal) Rat() *big.Rat {
+	r, ok := new(big.Rat).SetString(string(d))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Scale is the number of decimal places d needs.
+func (d Decimal) Scale() int {
+	_, fraction, _ := strings.Cut(string(d), ".")
+	return len(fraction)
+}
+
+func (d Decimal) String() string {
+	return string(d)
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	literal := string(bytes.TrimSpace(data))
+	if len(literal) > 0 && literal[0] == '"' {
+		if err := json.Unmarshal(data, &literal); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseDecimal(literal)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d == "" {
+		return []byte("0"), nil
+	}
+	return []byte(d), nil
+}
+
+const RuleMoneyScale = "money_scale"
+
+// CurrencyScale returns the minor units of an ISO 4217 currency, the
+// decimal places its amounts may have. Unknown or empty currencies get 2.
+func CurrencyScale(currency string) int {
+	if scale, ok := currencyScales[currency]; ok {
+		return scale
+	}
+	return 2
+}
+
+var currencyScales = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// checkMoney reports an amount with more decimal places than its currency
+// allows.
+func checkMoney(v *ValidationError, path string, amount Decimal, currency string) {
+	if amount == "" {
+		return
+	}
+	if scale := CurrencyScale(currency); amount.Scale() > scale {
+		name := currency
+		if name == "" {
+			name = "amounts without a currency"
+		} else {
+			name += " amounts"
+		}
+		v.Add(path, RuleMoneyScale, fmt.Sprintf("%s allow at most %d decimal places", name, scale))
+	}
+}
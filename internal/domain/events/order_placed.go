@@ -1,30 +1,46 @@
 package events
 
-import "fmt"
+import (
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
 
 type OrderPlacedV1 struct {
-	OrderID    string  `json:"order_id"`
-	UserID     string  `json:"user_id"`
-	Total      float64 `json:"total"`
-	ItemsCount int     `json:"items_count"`
-	Status     string  `json:"status"`
+	OrderID    string      `json:"order_id" validate:"required,id=ord order"`
+	UserID     string      `json:"user_id" validate:"required,uuid"`
+	Total      Decimal     `json:"total" validate:"required,gt=0"`
+	Currency   string      `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	ItemsCount int         `json:"items_count" validate:"gt=0"`
+	Status     OrderStatus `json:"status" validate:"required,enum"`
 }
 
+var orderPlacedV1Proto = newProtoDescriptor("OrderPlacedV1",
+	protoField{1, "order_id", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{2, "user_id", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{3, "total", descriptorpb.FieldDescriptorProto_TYPE_DOUBLE},
+	protoField{4, "items_count", descriptorpb.FieldDescriptorProto_TYPE_INT32},
+	protoField{5, "status", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{6, "currency", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+)
+
+var orderPlacedV1Avro = avro.MustParse(`{
+	"type": "record",
+	"name": "OrderPlacedV1",
+	"namespace": "events",
+	"fields": [
+		{"name": "order_id", "type": "string"},
+		{"name": "user_id", "type": "string"},
+		{"name": "total", "type": "double"},
+		{"name": "items_count", "type": "int"},
+		{"name": "status", "type": "string"},
+		{"name": "currency", "type": "string", "default": ""}
+	]
+}`)
+
 func (e *OrderPlacedV1) Validate() error {
-	if e.OrderID == "" {
-		return fmt.Errorf("order_id is required")
-	}
-	if e.UserID == "" {
-		return fmt.Errorf("user_id is required")
-	}
-	if e.Total <= 0 {
-		return fmt.Errorf("total must be greater than 0")
-	}
-	if e.ItemsCount <= 0 {
-		return fmt.Errorf("items_count must be greater than 0")
-	}
-	if e.Status == "" {
-		return fmt.Errorf("status is required")
-	}
-	return nil
+	return ValidateStruct(e)
+}
+
+func (e *OrderPlacedV1) ValidateFields(path string, v *ValidationError) {
+	checkMoney(v, path+".total", e.Total, e.Currency)
 }
@@ -1,30 +1,46 @@
 package events
 
-import "fmt"
+import (
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
 
 type PaymentProcessedV1 struct {
-	PaymentID     string  `json:"payment_id"`
-	OrderID       string  `json:"order_id"`
-	Amount        float64 `json:"amount"`
-	PaymentMethod string  `json:"payment_method"`
-	Status        string  `json:"status"`
+	PaymentID     string        `json:"payment_id" validate:"required,id=pay"`
+	OrderID       string        `json:"order_id" validate:"required,id=ord order"`
+	Amount        Decimal       `json:"amount" validate:"required,gt=0"`
+	Currency      string        `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	PaymentMethod PaymentMethod `json:"payment_method" validate:"required,enum"`
+	Status        PaymentStatus `json:"status" validate:"required,enum"`
 }
 
+var paymentProcessedV1Proto = newProtoDescriptor("PaymentProcessedV1",
+	protoField{1, "payment_id", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{2, "order_id", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{3, "amount", descriptorpb.FieldDescriptorProto_TYPE_DOUBLE},
+	protoField{4, "payment_method", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{5, "status", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{6, "currency", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+)
+
+var paymentProcessedV1Avro = avro.MustParse(`{
+	"type": "record",
+	"name": "PaymentProcessedV1",
+	"namespace": "events",
+	"fields": [
+		{"name": "payment_id", "type": "string"},
+		{"name": "order_id", "type": "string"},
+		{"name": "amount", "type": "double"},
+		{"name": "payment_method", "type": "string"},
+		{"name": "status", "type": "string"},
+		{"name": "currency", "type": "string", "default": ""}
+	]
+}`)
+
 func (e *PaymentProcessedV1) Validate() error {
-	if e.PaymentID == "" {
-		return fmt.Errorf("payment_id is required")
-	}
-	if e.OrderID == "" {
-		return fmt.Errorf("order_id is required")
-	}
-	if e.Amount <= 0 {
-		return fmt.Errorf("amount must be greater than 0")
-	}
-	if e.PaymentMethod == "" {
-		return fmt.Errorf("payment_method is required")
-	}
-	if e.Status == "" {
-		return fmt.Errorf("status is required")
-	}
-	return nil
+	return ValidateStruct(e)
+}
+
+func (e *PaymentProcessedV1) ValidateFields(path string, v *ValidationError) {
+	checkMoney(v, path+".amount", e.Amount, e.Currency)
 }
@@ -0,0 +1,219 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	RuleRequired    = "required"
+	RuleEmail       = "email"
+	RuleGreaterThan = "gt"
+	RuleMin         = "min"
+	RuleMax         = "max"
+	RuleOneOf       = "oneof"
+	RuleUUID        = "uuid"
+	RuleISO4217     = "iso4217"
+	RuleEnum        = "enum"
+	RuleID          = "id"
+)
+
+func init() {
+	rules[RuleEmail] = noParam(emailCheck)
+	rules[RuleUUID] = noParam(uuidCheck)
+	rules[RuleISO4217] = noParam(currencyCheck)
+	rules[RuleGreaterThan] = boundRule(func(n, bound float64) bool { return n > bound }, "greater than")
+	rules[RuleMin] = boundRule(func(n, bound float64) bool { return n >= bound }, "at least")
+	rules[RuleMax] = boundRule(func(n, bound float64) bool { return n <= bound }, "at most")
+	rules[RuleOneOf] = oneOfRule
+	rules[RuleEnum] = noParam(enumCheck)
+	rules[RuleID] = idRule
+}
+
+func noParam(check Check) Rule {
+	return func(param string) (Check, error) {
+		if param != "" {
+			return nil, errors.New("takes no parameter")
+		}
+		return check, nil
+	}
+}
+
+// emailCheck accepts a bare RFC 5322 address, without a display name, whose
+// domain has at least two labels and which fits the SMTP length limits.
+func emailCheck(value reflect.Value) string {
+	const invalid = "must be a valid email address"
+	if value.Kind() != reflect.String {
+		return "must be a string"
+	}
+
+	email := value.String()
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 254 {
+		return invalid
+	}
+
+	local, domain, _ := strings.Cut(email, "@")
+	if len(local) > 64 || !strings.Contains(domain, ".") {
+		return invalid
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 {
+			return invalid
+		}
+	}
+	return ""
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func uuidCheck(value reflect.Value) string {
+	if value.Kind() != reflect.String || !uuidPattern.MatchString(value.String()) {
+		return "must be a valid UUID"
+	}
+	return ""
+}
+
+func currencyCheck(value reflect.Value) string {
+	if value.Kind() != reflect.String || !iso4217Codes[value.String()] {
+		return "must be an ISO 4217 currency code"
+	}
+	return ""
+}
+
+// boundRule compares numbers by value and strings, slices and maps by
+// length against the rule's numeric parameter.
+func boundRule(ok func(n, bound float64) bool, relation string) Rule {
+	return func(param string) (Check, error) {
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parameter must be a number, got %q", param)
+		}
+
+		return func(value reflect.Value) string {
+			if n, isNumber := numberOf(value); isNumber {
+				if !ok(n, bound) {
+					return fmt.Sprintf("must be %s %s", relation, param)
+				}
+				return ""
+			}
+
+			n, unit := lengthOf(value)
+			if unit == "" {
+				return "must be a number, string or list"
+			}
+			if !ok(float64(n), bound) {
+				return fmt.Sprintf("must be %s %s %s", relation, param, unit)
+			}
+			return ""
+		}, nil
+	}
+}
+
+func enumCheck(value reflect.Value) string {
+	enum, ok := value.Interface().(Enum)
+	if !ok {
+		return "is not an enumerated type"
+	}
+	if !enum.Valid() {
+		return "must be one of " + strings.Join(enum.Values(), ", ")
+	}
+	return ""
+}
+
+// idRule accepts a UUID or an ID with one of the given prefixes, as in
+// id=ord order for "ord-123" and "order-9f2c".
+func idRule(param string) (Check, error) {
+	prefixes := strings.Fields(param)
+	if len(prefixes) == 0 {
+		return nil, errors.New("needs at least one prefix")
+	}
+
+	quoted := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		quoted[i] = regexp.QuoteMeta(prefix)
+	}
+	pattern := regexp.MustCompile(`^(` + strings.Join(quoted, "|") + `)-[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+	message := fmt.Sprintf("must be a UUID or an ID like %s-123", prefixes[0])
+
+	return func(value reflect.Value) string {
+		if value.Kind() != reflect.String {
+			return "must be a string"
+		}
+		if !uuidPattern.MatchString(value.String()) && !pattern.MatchString(value.String()) {
+			return message
+		}
+		return ""
+	}, nil
+}
+
+func oneOfRule(param string) (Check, error) {
+	options := strings.Fields(param)
+	if len(options) == 0 {
+		return nil, errors.New("needs at least one option")
+	}
+
+	allowed := make(map[string]bool, len(options))
+	for _, option := range options {
+		allowed[option] = true
+	}
+	message := "must be one of " + strings.Join(options, ", ")
+
+	return func(value reflect.Value) string {
+		if !allowed[fmt.Sprint(value.Interface())] {
+			return message
+		}
+		return ""
+	}, nil
+}
+
+func numberOf(value reflect.Value) (float64, bool) {
+	if value.Type() == decimalType {
+		return value.Interface().(Decimal).Float64(), true
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+func lengthOf(value reflect.Value) (int, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), "items"
+	}
+	return 0, ""
+}
+
+// iso4217Codes lists the active ISO 4217 alphabetic currency codes.
+var iso4217Codes = func() map[string]bool {
+	codes := map[string]bool{}
+	for _, code := range strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND
+		BOB BOV BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU
+		CRC CUC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS
+		GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY
+		KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA
+		MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD
+		OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK
+		SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD
+		TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU
+		XBA XBB XBC XBD XCD XDR XOF XPD XPF XPT XSU XUA YER ZAR ZMW ZWG ZWL`) {
+		codes[code] = true
+	}
+	return codes
+}()
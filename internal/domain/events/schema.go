@@ -1,56 +1,344 @@
 package events
 
 import (
-	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"sync"
 )
 
 type Schema interface {
 	Validate() error
 }
 
+type registration struct {
+	constructor func() Schema
+	codecs      map[string]Codec
+	source      string
+	shape       *Shape
+	fields      []FieldDescriptor
+	document    any
+}
+
+// SchemaRegistry holds the schemas registered in code and the set loaded from
+// a schema directory. The file set is replaced as a whole on every load.
 type SchemaRegistry struct {
-	schemas map[string]map[string]func() Schema
+	mu            sync.RWMutex
+	schemas       map[string]map[string]registration
+	files         map[string]map[string]registration
+	compatibility CompatibilityMode
+	modes         map[string]CompatibilityMode
+	upcasters     map[string]map[string]upcastStep
+
+	strictness          Strictness
+	strictnessOverrides map[string]Strictness
+}
+
+type SchemaInfo struct {
+	EventType    string   `json:"event_type"`
+	Version      string   `json:"version"`
+	ContentTypes []string `json:"content_types"`
+	Source       string   `json:"source"`
+
+	Fields []FieldDescriptor `json:"fields,omitempty"`
 }
 
 func NewSchemaRegistry() *SchemaRegistry {
 	registry := &SchemaRegistry{
-		schemas: make(map[string]map[string]func() Schema),
+		schemas:       make(map[string]map[string]registration),
+		files:         make(map[string]map[string]registration),
+		compatibility: CompatibilityBackward,
+		modes:         make(map[string]CompatibilityMode),
+		upcasters:     make(map[string]map[string]upcastStep),
+
+		strictness:          StrictnessLenient,
+		strictnessOverrides: make(map[string]Strictness),
 	}
 
-	registry.Register("payment.processed", "v1", func() Schema { return &PaymentProcessedV1{} })
-	registry.Register("user.created", "v1", func() Schema { return &UserCreatedV1{} })
-	registry.Register("order.placed", "v1", func() Schema { return &OrderPlacedV1{} })
+	builtins := []struct {
+		eventType   string
+		constructor func() Schema
+		codecs      []Codec
+	}{
+		{"payment.processed", func() Schema { return &PaymentProcessedV1{} },
+			[]Codec{JSONCodec{}, ProtobufCodec{Descriptor: paymentProcessedV1Proto}, AvroCodec{Schema: paymentProcessedV1Avro}}},
+		{"user.created", func() Schema { return &UserCreatedV1{} },
+			[]Codec{JSONCodec{}, ProtobufCodec{Descriptor: userCreatedV1Proto}, AvroCodec{Schema: userCreatedV1Avro}}},
+		{"order.placed", func() Schema { return &OrderPlacedV1{} },
+			[]Codec{JSONCodec{}, ProtobufCodec{Descriptor: orderPlacedV1Proto}, AvroCodec{Schema: orderPlacedV1Avro}}},
+	}
+	for _, builtin := range builtins {
+		if err := registry.Register(builtin.eventType, "v1", builtin.constructor, builtin.codecs...); err != nil {
+			panic(fmt.Sprintf("invalid built-in schema %s v1: %v", builtin.eventType, err))
+		}
+	}
 
 	return registry
 }
 
-func (r *SchemaRegistry) Register(eventType, version string, constructor func() Schema) {
-	if r.schemas[eventType] == nil {
-		r.schemas[eventType] = make(map[string]func() Schema)
+// Register adds a schema version accepted in the given formats, or only as
+// JSON when no codec is passed. It fails when the version breaks the event
+// type's compatibility mode against its neighbouring versions.
+func (r *SchemaRegistry) Register(eventType, version string, constructor func() Schema, codecs ...Codec) error {
+	schemaType := reflect.TypeOf(constructor())
+	if elem := structElem(schemaType); elem != nil {
+		if _, err := planOf(elem); err != nil {
+			return err
+		}
+	}
+
+	reg := newRegistration(constructor, "go", codecs...)
+	reg.fields = fieldsOfType(schemaType)
+	reg.shape = shapeOfFields(reg.fields)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.versions(eventType)
+	versions[version] = reg
+	if err := r.checkVersions(eventType, versions); err != nil {
+		return err
+	}
+
+	addRegistration(r.schemas, eventType, version, reg)
+	return nil
+}
+
+// SetCompatibility sets the mode new versions of eventType are checked
+// with. An empty eventType sets the default for every event type.
+func (r *SchemaRegistry) SetCompatibility(eventType string, mode CompatibilityMode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if eventType == "" {
+		r.compatibility = mode
+		return
+	}
+	r.modes[eventType] = mode
+}
+
+func (r *SchemaRegistry) Compatibility(eventType string) CompatibilityMode {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.compatibilityOf(eventType)
+}
+
+func (r *SchemaRegistry) compatibilityOf(eventType string) CompatibilityMode {
+	if mode, ok := r.modes[eventType]; ok {
+		return mode
+	}
+	return r.compatibility
+}
+
+// Shape returns the structure compatibility checks see for a version.
+func (r *SchemaRegistry) Shape(eventType, version string) (*Shape, error) {
+	reg, err := r.lookup(eventType, version)
+	if err != nil {
+		return nil, err
+	}
+	return reg.shape, nil
+}
+
+// versions returns a copy of every registered version of eventType.
+func (r *SchemaRegistry) versions(eventType string) map[string]registration {
+	versions := make(map[string]registration)
+	for version, reg := range r.files[eventType] {
+		versions[version] = reg
+	}
+	for version, reg := range r.schemas[eventType] {
+		versions[version] = reg
+	}
+	return versions
+}
+
+// checkVersions checks each version of eventType against the one before it.
+func (r *SchemaRegistry) checkVersions(eventType string, versions map[string]registration) error {
+	mode := r.compatibilityOf(eventType)
+	if mode == CompatibilityNone {
+		return nil
+	}
+
+	ordered := make([]string, 0, len(versions))
+	for version := range versions {
+		ordered = append(ordered, version)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return compareVersions(ordered[i], ordered[j]) < 0 })
+
+	for i := 1; i < len(ordered); i++ {
+		previous, next := versions[ordered[i-1]], versions[ordered[i]]
+		if issues := CheckCompatibility(mode, previous.shape, next.shape); len(issues) > 0 {
+			return &CompatibilityError{
+				EventType: eventType,
+				Version:   ordered[i],
+				Previous:  ordered[i-1],
+				Mode:      mode,
+				Issues:    issues,
+			}
+		}
+	}
+	return nil
+}
+
+func newRegistration(constructor func() Schema, source string, codecs ...Codec) registration {
+	if len(codecs) == 0 {
+		codecs = []Codec{JSONCodec{}}
+	}
+
+	reg := registration{
+		constructor: constructor,
+		codecs:      make(map[string]Codec, len(codecs)),
+		source:      source,
+	}
+	for _, codec := range codecs {
+		reg.codecs[codec.ContentType()] = codec
+	}
+	return reg
+}
+
+func addRegistration(set map[string]map[string]registration, eventType, version string, reg registration) {
+	if set[eventType] == nil {
+		set[eventType] = make(map[string]registration)
 	}
-	r.schemas[eventType][version] = constructor
+	set[eventType][version] = reg
+}
+
+// Schemas lists every registered event type and version with the fields
+// of its body.
+func (r *SchemaRegistry) Schemas() []SchemaInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var infos []SchemaInfo
+	for _, set := range []map[string]map[string]registration{r.schemas, r.files} {
+		for eventType, versions := range set {
+			for version, reg := range versions {
+				contentTypes := make([]string, 0, len(reg.codecs))
+				for contentType := range reg.codecs {
+					contentTypes = append(contentTypes, contentType)
+				}
+				sort.Strings(contentTypes)
+
+				infos = append(infos, SchemaInfo{
+					EventType:    eventType,
+					Version:      version,
+					ContentTypes: contentTypes,
+					Source:       reg.source,
+					Fields:       reg.fields,
+				})
+			}
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].EventType != infos[j].EventType {
+			return infos[i].EventType < infos[j].EventType
+		}
+		return infos[i].Version < infos[j].Version
+	})
+	return infos
 }
 
 func (r *SchemaRegistry) Unmarshal(eventType, version string, data []byte) (Schema, error) {
-	versions, ok := r.schemas[eventType]
-	if !ok {
-		return nil, fmt.Errorf("unknown event type: %s", eventType)
+	return r.Decode(eventType, version, ContentTypeJSON, data)
+}
+
+func (r *SchemaRegistry) Decode(eventType, version, contentType string, data []byte) (Schema, error) {
+	schema, _, err := r.DecodeWithWarnings(eventType, version, contentType, data)
+	return schema, err
+}
+
+// DecodeWithWarnings decodes like Decode and also returns what lenient
+// decoding let through: unknown fields and duplicate keys in a JSON body.
+// In strict mode those fail the decode instead. Numbers that lose precision
+// as float64 always do.
+func (r *SchemaRegistry) DecodeWithWarnings(eventType, version, contentType string, data []byte) (Schema, []ValidationIssue, error) {
+	reg, err := r.lookup(eventType, version)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	constructor, ok := versions[version]
-	if !ok {
-		return nil, fmt.Errorf("unknown schema version %s for event type %s", version, eventType)
+	codec, err := reg.codec(eventType, version, contentType)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	schema := constructor()
-	if err := json.Unmarshal(data, schema); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal event body: %w", err)
+	var warnings []ValidationIssue
+	if codec.ContentType() == ContentTypeJSON {
+		strict := r.Strictness(eventType, version) == StrictnessStrict
+
+		var rejected ValidationError
+		for _, issue := range inspectJSON(data, reg.shape) {
+			if strict || issue.Rule == RulePrecision {
+				rejected.Issues = append(rejected.Issues, issue)
+				continue
+			}
+			warnings = append(warnings, issue)
+		}
+		if err := rejected.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal event body: %w", err)
+		}
+
+		if strict {
+			codec = JSONCodec{DisallowUnknownFields: true}
+		}
+	}
+
+	schema := reg.constructor()
+	if err := codec.Decode(data, schema); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal event body: %w", err)
 	}
 
 	if err := schema.Validate(); err != nil {
-		return nil, fmt.Errorf("schema validation failed: %w", err)
+		return nil, nil, fmt.Errorf("schema validation failed: %w", err)
+	}
+
+	return schema, warnings, nil
+}
+
+func (r *SchemaRegistry) Codec(eventType, version, contentType string) (Codec, error) {
+	reg, err := r.lookup(eventType, version)
+	if err != nil {
+		return nil, err
+	}
+	return reg.codec(eventType, version, contentType)
+}
+
+// Known reports whether any version of eventType is registered.
+func (r *SchemaRegistry) Known(eventType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.schemas[eventType] != nil || r.files[eventType] != nil
+}
+
+func (r *SchemaRegistry) lookup(eventType, version string) (registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.schemas[eventType]
+	if !ok {
+		versions, ok = r.files[eventType]
+	}
+	if !ok {
+		return registration{}, fmt.Errorf("unknown event type: %s", eventType)
 	}
 
-	return schema, nil
+	reg, ok := versions[version]
+	if !ok {
+		reg, ok = r.files[eventType][version]
+	}
+	if !ok {
+		return registration{}, fmt.Errorf("unknown schema version %s for event type %s", version, eventType)
+	}
+
+	return reg, nil
+}
+
+func (reg registration) codec(eventType, version, contentType string) (Codec, error) {
+	codec, ok := reg.codecs[NormalizeContentType(contentType)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type %s for event type %s version %s", contentType, eventType, version)
+	}
+	return codec, nil
 }
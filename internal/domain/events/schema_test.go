@@ -1,6 +1,7 @@
 package events_test
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -13,7 +14,7 @@ func TestSchemaRegistry(t *testing.T) {
 
 	t.Run("should unmarshal valid user.created event", func(t *testing.T) {
 		data := []byte(`{
-			"user_id": "123",
+			"user_id": "8f14e45f-ceea-4c3b-9e5a-2f0c1e9b7d21",
 			"email": "test@example.com",
 			"name": "Test User",
 			"role": "admin",
@@ -26,16 +27,16 @@ func TestSchemaRegistry(t *testing.T) {
 
 		event, ok := schema.(*events.UserCreatedV1)
 		assert.True(t, ok)
-		assert.Equal(t, "123", event.UserID)
+		assert.Equal(t, "8f14e45f-ceea-4c3b-9e5a-2f0c1e9b7d21", event.UserID)
 	})
 
 	t.Run("should unmarshal valid order.placed event", func(t *testing.T) {
 		data := []byte(`{
 			"order_id": "ord-1",
-			"user_id": "u-1",
+			"user_id": "3b2f7c9e-5d1a-4e8b-9c6f-0a4d2e7b1c58",
 			"total": 100.50,
 			"items_count": 2,
-			"status": "confirmed"
+			"status": "CONFIRMED"
 		}`)
 
 		schema, err := registry.Unmarshal("order.placed", "v1", data)
@@ -92,6 +93,22 @@ func TestSchemaRegistry(t *testing.T) {
 	})
 }
 
+func TestSchemaRegistry_ValidationError(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+
+	_, err := registry.Unmarshal("order.placed", "v1", []byte(`{"user_id": "3b2f7c9e-5d1a-4e8b-9c6f-0a4d2e7b1c58", "total": -1}`))
+
+	var validationErr *events.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []events.ValidationIssue{
+		{Path: "$.items_count", Rule: events.RuleGreaterThan, Message: "must be greater than 0"},
+		{Path: "$.order_id", Rule: events.RuleRequired, Message: "is required"},
+		{Path: "$.status", Rule: events.RuleRequired, Message: "is required"},
+		{Path: "$.total", Rule: events.RuleGreaterThan, Message: "must be greater than 0"},
+	}, validationErr.Issues)
+	assert.ErrorContains(t, err, "$.items_count: must be greater than 0; $.order_id: is required")
+}
+
 func TestUserCreatedV1_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -153,7 +170,7 @@ func TestOrderPlacedV1_Validate(t *testing.T) {
 			event: events.OrderPlacedV1{
 				OrderID:    gofakeit.UUID(),
 				UserID:     gofakeit.UUID(),
-				Total:      gofakeit.Price(10, 1000),
+				Total:      events.Decimal(strconv.Itoa(gofakeit.Number(10, 1000))),
 				ItemsCount: gofakeit.Number(1, 10),
 				Status:     "PENDING",
 			},
@@ -161,27 +178,27 @@ func TestOrderPlacedV1_Validate(t *testing.T) {
 		},
 		{
 			name:    "missing order_id",
-			event:   events.OrderPlacedV1{UserID: gofakeit.UUID(), Total: 100, ItemsCount: 1, Status: "PENDING"},
+			event:   events.OrderPlacedV1{UserID: gofakeit.UUID(), Total: "100", ItemsCount: 1, Status: "PENDING"},
 			wantErr: true,
 		},
 		{
 			name:    "invalid total",
-			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), UserID: gofakeit.UUID(), Total: 0, ItemsCount: 1, Status: "PENDING"},
+			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), UserID: gofakeit.UUID(), Total: "0", ItemsCount: 1, Status: "PENDING"},
 			wantErr: true,
 		},
 		{
 			name:    "invalid items count",
-			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), UserID: gofakeit.UUID(), Total: 100, ItemsCount: 0, Status: "PENDING"},
+			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), UserID: gofakeit.UUID(), Total: "100", ItemsCount: 0, Status: "PENDING"},
 			wantErr: true,
 		},
 		{
 			name:    "missing user_id",
-			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), Total: 100, ItemsCount: 1, Status: "PENDING"},
+			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), Total: "100", ItemsCount: 1, Status: "PENDING"},
 			wantErr: true,
 		},
 		{
 			name:    "missing status",
-			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), UserID: gofakeit.UUID(), Total: 100, ItemsCount: 1},
+			event:   events.OrderPlacedV1{OrderID: gofakeit.UUID(), UserID: gofakeit.UUID(), Total: "100", ItemsCount: 1},
 			wantErr: true,
 		},
 	}
@@ -209,7 +226,7 @@ func TestPaymentProcessedV1_Validate(t *testing.T) {
 			event: events.PaymentProcessedV1{
 				PaymentID:     gofakeit.UUID(),
 				OrderID:       gofakeit.UUID(),
-				Amount:        gofakeit.Price(10, 500),
+				Amount:        "149.90",
 				PaymentMethod: "CREDIT_CARD",
 				Status:        "SUCCESS",
 			},
@@ -217,27 +234,27 @@ func TestPaymentProcessedV1_Validate(t *testing.T) {
 		},
 		{
 			name:    "missing payment_id",
-			event:   events.PaymentProcessedV1{OrderID: gofakeit.UUID(), Amount: 100, PaymentMethod: "CREDIT_CARD", Status: "SUCCESS"},
+			event:   events.PaymentProcessedV1{OrderID: gofakeit.UUID(), Amount: "100", PaymentMethod: "CREDIT_CARD", Status: "SUCCESS"},
 			wantErr: true,
 		},
 		{
 			name:    "invalid amount",
-			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), OrderID: gofakeit.UUID(), Amount: 0, PaymentMethod: "CREDIT_CARD", Status: "SUCCESS"},
+			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), OrderID: gofakeit.UUID(), Amount: "0", PaymentMethod: "CREDIT_CARD", Status: "SUCCESS"},
 			wantErr: true,
 		},
 		{
 			name:    "missing order_id",
-			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), Amount: 100, PaymentMethod: "CREDIT_CARD", Status: "SUCCESS"},
+			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), Amount: "100", PaymentMethod: "CREDIT_CARD", Status: "SUCCESS"},
 			wantErr: true,
 		},
 		{
 			name:    "missing payment_method",
-			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), OrderID: gofakeit.UUID(), Amount: 100, Status: "SUCCESS"},
+			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), OrderID: gofakeit.UUID(), Amount: "100", Status: "SUCCESS"},
 			wantErr: true,
 		},
 		{
 			name:    "missing status",
-			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), OrderID: gofakeit.UUID(), Amount: 100, PaymentMethod: "CREDIT_CARD"},
+			event:   events.PaymentProcessedV1{PaymentID: gofakeit.UUID(), OrderID: gofakeit.UUID(), Amount: "100", PaymentMethod: "CREDIT_CARD"},
 			wantErr: true,
 		},
 	}
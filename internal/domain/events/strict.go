@@ -0,0 +1,205 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Strictness decides what happens to unknown fields and duplicate keys in a
+// JSON body. Strict rejects the event; lenient accepts it and reports them as
+// warnings.
+type Strictness string
+
+const (
+	StrictnessLenient Strictness = "lenient"
+	StrictnessStrict  Strictness = "strict"
+)
+
+const (
+	RuleUnknownField = "unknown_field"
+	RuleDuplicateKey = "duplicate_key"
+	RulePrecision    = "precision"
+)
+
+func ParseStrictness(s string) (Strictness, error) {
+	switch strictness := Strictness(strings.ToLower(strings.TrimSpace(s))); strictness {
+	case StrictnessLenient, StrictnessStrict:
+		return strictness, nil
+	}
+	return "", fmt.Errorf("unknown strictness: %s", s)
+}
+
+// ParseStrictnessOverrides parses per event type or version settings written
+// as "payment.processed=strict,user.created:v1=lenient".
+func ParseStrictnessOverrides(s string) (map[string]Strictness, error) {
+	overrides := map[string]Strictness{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		selector, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(selector) == "" {
+			return nil, fmt.Errorf("invalid strictness override: %s", entry)
+		}
+		strictness, err := ParseStrictness(value)
+		if err != nil {
+			return nil, err
+		}
+		overrides[strings.TrimSpace(selector)] = strictness
+	}
+	return overrides, nil
+}
+
+// SetStrictness sets how bodies are decoded for selector, which is an event
+// type, an event type and version as "user.created:v1", or empty for the
+// default. A version setting wins over its event type's.
+func (r *SchemaRegistry) SetStrictness(selector string, strictness Strictness) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if selector == "" {
+		r.strictness = strictness
+		return
+	}
+	r.strictnessOverrides[selector] = strictness
+}
+
+func (r *SchemaRegistry) Strictness(eventType, version string) Strictness {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if strictness, ok := r.strictnessOverrides[eventType+":"+version]; ok {
+		return strictness
+	}
+	if strictness, ok := r.strictnessOverrides[eventType]; ok {
+		return strictness
+	}
+	return r.strictness
+}
+
+// inspectJSON reports duplicate keys, fields the shape does not declare and
+// numbers float64 cannot hold exactly. A nil shape, or one without fields,
+// accepts any field. Malformed JSON is left for the codec to report.
+func inspectJSON(data []byte, shape *Shape) []ValidationIssue {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	inspector := &jsonInspector{dec: dec}
+	_ = inspector.value("$", shape)
+	return inspector.issues
+}
+
+type jsonInspector struct {
+	dec    *json.Decoder
+	issues []ValidationIssue
+}
+
+func (in *jsonInspector) add(path, rule, message string) {
+	in.issues = append(in.issues, ValidationIssue{Path: path, Rule: rule, Message: message})
+}
+
+func (in *jsonInspector) value(path string, shape *Shape) error {
+	tok, err := in.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Number:
+		if losesPrecision(t.String()) {
+			in.add(path, RulePrecision, fmt.Sprintf("number %s cannot be represented exactly", t))
+		}
+	case json.Delim:
+		switch t {
+		case '{':
+			return in.object(path, shape)
+		case '[':
+			var items *Shape
+			if shape != nil {
+				items = shape.Items
+			}
+			for i := 0; in.dec.More(); i++ {
+				if err := in.value(path+"["+strconv.Itoa(i)+"]", items); err != nil {
+					return err
+				}
+			}
+			_, err := in.dec.Token()
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *jsonInspector) object(path string, shape *Shape) error {
+	seen := map[string]bool{}
+	for in.dec.More() {
+		tok, err := in.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		fieldPath := path + "." + key
+
+		if seen[key] {
+			in.add(fieldPath, RuleDuplicateKey, "is a duplicate key")
+		}
+		seen[key] = true
+
+		var field *Shape
+		if shape != nil && shape.Fields != nil {
+			var ok bool
+			if field, ok = fieldShape(shape, key); !ok {
+				in.add(fieldPath, RuleUnknownField, "is not a known field")
+			}
+		}
+
+		if err := in.value(fieldPath, field); err != nil {
+			return err
+		}
+	}
+	_, err := in.dec.Token()
+	return err
+}
+
+// fieldShape matches keys the way encoding/json does, preferring an exact
+// match over a case-insensitive one.
+func fieldShape(shape *Shape, key string) (*Shape, bool) {
+	if field, ok := shape.Fields[key]; ok {
+		return field, true
+	}
+	for name, field := range shape.Fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// losesPrecision reports whether a JSON number changes when decoded into a
+// float64, comparing the literal with the shortest decimal that float64
+// round-trips to. 0.1 is fine; 9007199254740993 is not.
+func losesPrecision(literal string) bool {
+	// Exponents beyond float64's range would make big.Rat expand huge
+	// powers of ten; such values cannot decode exactly anyway.
+	if _, digits, exp, err := decimalParts(literal); err != nil || exp+len(digits) > 400 || exp < -400 {
+		return err != nil || digits != ""
+	}
+
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return true
+	}
+
+	exact, ok := new(big.Rat).SetString(literal)
+	if !ok {
+		return true
+	}
+	decoded, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return exact.Cmp(decoded) != 0
+}
@@ -0,0 +1,132 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+const paymentWithTypo = `{
+	"payment_id": "pay-1",
+	"order_id": "ord-1",
+	"amount": 10,
+	"ammount": 100,
+	"payment_method": "credit_card",
+	"status": "success",
+	"status": "failed"
+}`
+
+func TestSchemaRegistry_Strictness(t *testing.T) {
+	t.Run("lenient decoding returns unknown fields and duplicate keys as warnings", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+
+		schema, warnings, err := registry.DecodeWithWarnings("payment.processed", "v1", events.ContentTypeJSON, []byte(paymentWithTypo))
+		assert.NoError(t, err)
+		assert.Equal(t, events.PaymentStatusFailed, schema.(*events.PaymentProcessedV1).Status)
+		assert.Equal(t, []events.ValidationIssue{
+			{Path: "$.ammount", Rule: events.RuleUnknownField, Message: "is not a known field"},
+			{Path: "$.status", Rule: events.RuleDuplicateKey, Message: "is a duplicate key"},
+		}, warnings)
+	})
+
+	t.Run("strict decoding rejects them", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		registry.SetStrictness("payment.processed", events.StrictnessStrict)
+
+		_, err := registry.Unmarshal("payment.processed", "v1", []byte(paymentWithTypo))
+		assert.ErrorContains(t, err, "$.ammount: is not a known field; $.status: is a duplicate key")
+
+		var validationErr *events.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("version settings win over event type settings", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		registry.SetStrictness("", events.StrictnessStrict)
+		registry.SetStrictness("payment.processed:v1", events.StrictnessLenient)
+
+		assert.Equal(t, events.StrictnessLenient, registry.Strictness("payment.processed", "v1"))
+		assert.Equal(t, events.StrictnessStrict, registry.Strictness("payment.processed", "v2"))
+		assert.Equal(t, events.StrictnessStrict, registry.Strictness("order.placed", "v1"))
+
+		_, err := registry.Unmarshal("payment.processed", "v1", []byte(paymentWithTypo))
+		assert.NoError(t, err)
+	})
+
+	t.Run("matches field names case-insensitively like encoding/json", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		registry.SetStrictness("", events.StrictnessStrict)
+
+		_, err := registry.Unmarshal("payment.processed", "v1", []byte(`{
+			"Payment_ID": "pay-1",
+			"order_id": "ord-1",
+			"amount": 10,
+			"payment_method": "credit_card",
+			"status": "success"
+		}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects numbers that lose precision in any mode", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+
+		_, err := registry.Unmarshal("payment.processed", "v1", []byte(`{
+			"payment_id": "pay-1",
+			"order_id": "ord-1",
+			"amount": 9007199254740993,
+			"payment_method": "credit_card",
+			"status": "success"
+		}`))
+		assert.ErrorContains(t, err, "$.amount: number 9007199254740993 cannot be represented exactly")
+
+		_, err = registry.Unmarshal("payment.processed", "v1", []byte(`{
+			"payment_id": "pay-1",
+			"order_id": "ord-1",
+			"amount": 1e-200000,
+			"payment_method": "credit_card",
+			"status": "success"
+		}`))
+		assert.ErrorContains(t, err, "$.amount: number 1e-200000 cannot be represented exactly")
+	})
+
+	t.Run("accepts numbers float64 round-trips", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+
+		for _, amount := range []string{"0.1", "100.50", "1e3", "9007199254740992"} {
+			_, err := registry.Unmarshal("payment.processed", "v1", []byte(`{
+				"payment_id": "pay-1",
+				"order_id": "ord-1",
+				"amount": `+amount+`,
+				"payment_method": "credit_card",
+				"status": "success"
+			}`))
+			assert.NoError(t, err, amount)
+		}
+	})
+
+	t.Run("checks JSON Schema events against the fields of their document", func(t *testing.T) {
+		registry := events.NewSchemaRegistry()
+		assert.NoError(t, registry.LoadDir("testdata/schemas"))
+
+		invoice := []byte(`{
+			"invoice_id": "inv-1",
+			"customer_email": "billing@example.com",
+			"amount": 10,
+			"currency": "BRL",
+			"lines": [{"sku": "sku-1", "quantity": 1, "discount": 0}],
+			"notes": "extra"
+		}`)
+
+		_, warnings, err := registry.DecodeWithWarnings("invoice.issued", "v1", events.ContentTypeJSON, invoice)
+		assert.NoError(t, err)
+		assert.Equal(t, []events.ValidationIssue{
+			{Path: "$.lines[0].discount", Rule: events.RuleUnknownField, Message: "is not a known field"},
+			{Path: "$.notes", Rule: events.RuleUnknownField, Message: "is not a known field"},
+		}, warnings)
+
+		registry.SetStrictness("invoice.issued", events.StrictnessStrict)
+		_, _, err = registry.DecodeWithWarnings("invoice.issued", "v1", events.ContentTypeJSON, invoice)
+		assert.ErrorContains(t, err, "$.lines[0].discount: is not a known field; $.notes: is not a known field")
+	})
+}
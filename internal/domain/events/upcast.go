@@ -0,0 +1,73 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Upcaster turns an event of one schema version into the next version.
+type Upcaster func(Schema) (Schema, error)
+
+type upcastStep struct {
+	to       string
+	upcaster Upcaster
+}
+
+// RegisterUpcaster adds the step that moves eventType from one version to a
+// newer one. Steps are chained, so registering v1→v2 and v2→v3 upcasts a v1
+// event to v3.
+func (r *SchemaRegistry) RegisterUpcaster(eventType, from, to string, upcaster Upcaster) error {
+	if compareVersions(from, to) >= 0 {
+		return fmt.Errorf("upcaster for %s must move to a newer version, got %s to %s", eventType, from, to)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if step, ok := r.upcasters[eventType][from]; ok {
+		return fmt.Errorf("upcaster for %s %s is already registered to %s", eventType, from, step.to)
+	}
+
+	if r.upcasters[eventType] == nil {
+		r.upcasters[eventType] = make(map[string]upcastStep)
+	}
+	r.upcasters[eventType][from] = upcastStep{to: to, upcaster: upcaster}
+	return nil
+}
+
+// Upcast runs schema through every upcaster registered from version onwards
+// and returns the result with the version it ended at. Each step's output is
+// decoded and validated against its target version, so an upcaster may
+// return any value that marshals to that version's JSON.
+func (r *SchemaRegistry) Upcast(eventType, version string, schema Schema) (Schema, string, error) {
+	for {
+		step, ok := r.upcastStep(eventType, version)
+		if !ok {
+			return schema, version, nil
+		}
+
+		next, err := step.upcaster(schema)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upcast %s from %s to %s: %w", eventType, version, step.to, err)
+		}
+
+		data, err := json.Marshal(next)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upcast %s from %s to %s: %w", eventType, version, step.to, err)
+		}
+
+		schema, err = r.Unmarshal(eventType, step.to, data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upcast %s from %s to %s: %w", eventType, version, step.to, err)
+		}
+		version = step.to
+	}
+}
+
+func (r *SchemaRegistry) upcastStep(eventType, version string) (upcastStep, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	step, ok := r.upcasters[eventType][version]
+	return step, ok
+}
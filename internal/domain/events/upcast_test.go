@@ -0,0 +1,129 @@
+package events_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type accountOpenedV1 struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+}
+
+func (e *accountOpenedV1) Validate() error { return nil }
+
+type accountOpenedV2 struct {
+	AccountID   string `json:"account_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+func (e *accountOpenedV2) Validate() error {
+	if e.DisplayName == "" {
+		return errors.New("display_name is required")
+	}
+	return nil
+}
+
+type accountOpenedV3 struct {
+	AccountID   string   `json:"account_id"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+func (e *accountOpenedV3) Validate() error { return nil }
+
+func newAccountRegistry(t *testing.T) *events.SchemaRegistry {
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.Register("account.opened", "v1", func() events.Schema { return &accountOpenedV1{} }))
+	assert.NoError(t, registry.Register("account.opened", "v2", func() events.Schema { return &accountOpenedV2{} }))
+	assert.NoError(t, registry.Register("account.opened", "v3", func() events.Schema { return &accountOpenedV3{} }))
+	return registry
+}
+
+func TestSchemaRegistry_Upcast(t *testing.T) {
+	v1ToV2 := func(s events.Schema) (events.Schema, error) {
+		v1 := s.(*accountOpenedV1)
+		return &accountOpenedV2{AccountID: v1.AccountID, Name: v1.Name, DisplayName: strings.ToUpper(v1.Name)}, nil
+	}
+	v2ToV3 := func(s events.Schema) (events.Schema, error) {
+		v2 := s.(*accountOpenedV2)
+		return &accountOpenedV3{AccountID: v2.AccountID, Name: v2.Name, DisplayName: v2.DisplayName, Tags: []string{"legacy"}}, nil
+	}
+
+	t.Run("chains every hop to the latest version", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v2", "v3", v2ToV3))
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v1", "v2", v1ToV2))
+
+		schema, err := registry.Unmarshal("account.opened", "v1", []byte(`{"account_id": "acc-1", "name": "ada"}`))
+		assert.NoError(t, err)
+
+		upcast, version, err := registry.Upcast("account.opened", "v1", schema)
+		assert.NoError(t, err)
+		assert.Equal(t, "v3", version)
+		assert.Equal(t, &accountOpenedV3{AccountID: "acc-1", Name: "ada", DisplayName: "ADA", Tags: []string{"legacy"}}, upcast)
+	})
+
+	t.Run("starts from the version the event was written with", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v1", "v2", v1ToV2))
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v2", "v3", v2ToV3))
+
+		schema, err := registry.Unmarshal("account.opened", "v2", []byte(`{"account_id": "acc-1", "name": "ada", "display_name": "Ada"}`))
+		assert.NoError(t, err)
+
+		upcast, version, err := registry.Upcast("account.opened", "v2", schema)
+		assert.NoError(t, err)
+		assert.Equal(t, "v3", version)
+		assert.Equal(t, "Ada", upcast.(*accountOpenedV3).DisplayName)
+	})
+
+	t.Run("leaves events without upcasters untouched", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		schema := &accountOpenedV1{AccountID: "acc-1", Name: "ada"}
+
+		upcast, version, err := registry.Upcast("account.opened", "v1", schema)
+		assert.NoError(t, err)
+		assert.Equal(t, "v1", version)
+		assert.Same(t, schema, upcast)
+	})
+
+	t.Run("validates each hop against its target version", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v1", "v2", func(s events.Schema) (events.Schema, error) {
+			return &accountOpenedV2{AccountID: s.(*accountOpenedV1).AccountID}, nil
+		}))
+
+		_, _, err := registry.Upcast("account.opened", "v1", &accountOpenedV1{AccountID: "acc-1", Name: "ada"})
+		assert.ErrorContains(t, err, "failed to upcast account.opened from v1 to v2")
+		assert.ErrorContains(t, err, "display_name is required")
+	})
+
+	t.Run("returns upcaster errors", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v1", "v2", func(events.Schema) (events.Schema, error) {
+			return nil, errors.New("boom")
+		}))
+
+		_, _, err := registry.Upcast("account.opened", "v1", &accountOpenedV1{})
+		assert.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("rejects steps that do not move forward", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		assert.Error(t, registry.RegisterUpcaster("account.opened", "v2", "v1", v1ToV2))
+		assert.Error(t, registry.RegisterUpcaster("account.opened", "v2", "v2", v1ToV2))
+	})
+
+	t.Run("rejects a second step from the same version", func(t *testing.T) {
+		registry := newAccountRegistry(t)
+		assert.NoError(t, registry.RegisterUpcaster("account.opened", "v1", "v2", v1ToV2))
+		assert.ErrorContains(t, registry.RegisterUpcaster("account.opened", "v1", "v3", v1ToV2), "already registered")
+	})
+}
@@ -1,27 +1,39 @@
 package events
 
-import "fmt"
+import (
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
 
 type UserCreatedV1 struct {
-	UserID   string `json:"user_id"`
-	Email    string `json:"email"`
-	Name     string `json:"name"`
-	Role     string `json:"role"`
+	UserID   string `json:"user_id" validate:"required,uuid"`
+	Email    string `json:"email" validate:"required,email"`
+	Name     string `json:"name" validate:"required,max=200"`
+	Role     string `json:"role" validate:"required"`
 	Verified bool   `json:"verified"`
 }
 
+var userCreatedV1Proto = newProtoDescriptor("UserCreatedV1",
+	protoField{1, "user_id", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{2, "email", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{3, "name", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{4, "role", descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	protoField{5, "verified", descriptorpb.FieldDescriptorProto_TYPE_BOOL},
+)
+
+var userCreatedV1Avro = avro.MustParse(`{
+	"type": "record",
+	"name": "UserCreatedV1",
+	"namespace": "events",
+	"fields": [
+		{"name": "user_id", "type": "string"},
+		{"name": "email", "type": "string"},
+		{"name": "name", "type": "string"},
+		{"name": "role", "type": "string"},
+		{"name": "verified", "type": "boolean"}
+	]
+}`)
+
 func (e *UserCreatedV1) Validate() error {
-	if e.UserID == "" {
-		return fmt.Errorf("user_id is required")
-	}
-	if e.Email == "" {
-		return fmt.Errorf("email is required")
-	}
-	if e.Name == "" {
-		return fmt.Errorf("name is required")
-	}
-	if e.Role == "" {
-		return fmt.Errorf("role is required")
-	}
-	return nil
+	return ValidateStruct(e)
 }
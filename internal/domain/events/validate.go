@@ -0,0 +1,256 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Check validates a single field value and returns the violation message,
+// or an empty string when the value is valid.
+type Check func(value reflect.Value) string
+
+// Rule builds the Check for a validate tag entry from its parameter, e.g.
+// "0" for gt=0. It runs once per struct type, so parsing belongs here.
+type Rule func(param string) (Check, error)
+
+const (
+	tagRequired  = "required"
+	tagOmitEmpty = "omitempty"
+)
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{}
+
+	plansMu sync.RWMutex
+	plans   = map[reflect.Type]*structPlan{}
+)
+
+// RegisterRule makes a rule available to validate tags under name. It
+// replaces any rule already registered with that name.
+func RegisterRule(name string, rule Rule) {
+	rulesMu.Lock()
+	rules[name] = rule
+	rulesMu.Unlock()
+
+	plansMu.Lock()
+	plans = map[reflect.Type]*structPlan{}
+	plansMu.Unlock()
+}
+
+// FieldsValidator is implemented by schema structs with rules that span
+// several fields. It runs after the tag rules, with the struct's JSON path.
+type FieldsValidator interface {
+	ValidateFields(path string, v *ValidationError)
+}
+
+type fieldCheck struct {
+	rule  string
+	check Check
+}
+
+type fieldPlan struct {
+	index     int
+	name      string
+	required  bool
+	omitEmpty bool
+	checks    []fieldCheck
+	nested    *structPlan
+}
+
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// ValidateStruct checks v, a struct or a pointer to one, against the
+// validate tags on its fields and on the fields of nested structs and
+// slices of structs. Violations come back as a *ValidationError.
+func ValidateStruct(v any) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return fmt.Errorf("cannot validate a nil %s", value.Type())
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot validate %s, expected a struct", value.Type())
+	}
+
+	plan, err := planOf(value.Type())
+	if err != nil {
+		return err
+	}
+
+	var out ValidationError
+	plan.validate(value, "$", &out)
+	return out.Err()
+}
+
+// planOf returns the cached plan of t under a read lock, so validating
+// messages in parallel does not contend, and only builds it under the write
+// lock the first time.
+func planOf(t reflect.Type) (*structPlan, error) {
+	plansMu.RLock()
+	plan, ok := plans[t]
+	plansMu.RUnlock()
+	if ok {
+		return plan, nil
+	}
+
+	plansMu.Lock()
+	defer plansMu.Unlock()
+
+	return buildPlan(t)
+}
+
+// buildPlan must be called with plansMu held. The plan is cached before its
+// fields are built so recursive types resolve to themselves.
+func buildPlan(t reflect.Type) (*structPlan, error) {
+	if plan, ok := plans[t]; ok {
+		return plan, nil
+	}
+
+	plan := &structPlan{}
+	plans[t] = plan
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fp := fieldPlan{index: i, name: name}
+		if tag := field.Tag.Get("validate"); tag != "" {
+			if err := fp.parseTag(tag); err != nil {
+				delete(plans, t)
+				return nil, fmt.Errorf("invalid validate tag on %s.%s: %w", t.Name(), field.Name, err)
+			}
+		}
+
+		if elem := structElem(field.Type); elem != nil {
+			nested, err := buildPlan(elem)
+			if err != nil {
+				delete(plans, t)
+				return nil, err
+			}
+			fp.nested = nested
+		}
+
+		if fp.required || len(fp.checks) > 0 || fp.nested != nil {
+			plan.fields = append(plan.fields, fp)
+		}
+	}
+	return plan, nil
+}
+
+func (fp *fieldPlan) parseTag(tag string) error {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+
+	for _, entry := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		switch name {
+		case tagRequired:
+			fp.required = true
+			continue
+		case tagOmitEmpty:
+			fp.omitEmpty = true
+			continue
+		}
+
+		rule, ok := rules[name]
+		if !ok {
+			return fmt.Errorf("unknown rule %q", name)
+		}
+		check, err := rule(param)
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", name, err)
+		}
+		fp.checks = append(fp.checks, fieldCheck{rule: name, check: check})
+	}
+	return nil
+}
+
+// structElem returns the struct type validated inside a field of type t,
+// for struct, pointer to struct and slice of struct fields.
+func structElem(t reflect.Type) reflect.Type {
+	t = derefType(t)
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = derefType(t.Elem())
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	return t
+}
+
+func (p *structPlan) validate(value reflect.Value, path string, out *ValidationError) {
+	for _, fp := range p.fields {
+		fp.validate(value.Field(fp.index), path+"."+fp.name, out)
+	}
+
+	if value.CanAddr() {
+		value = value.Addr()
+	}
+	if fields, ok := value.Interface().(FieldsValidator); ok {
+		fields.ValidateFields(path, out)
+	}
+}
+
+func (fp fieldPlan) validate(value reflect.Value, path string, out *ValidationError) {
+	if value.IsZero() {
+		if fp.required {
+			out.Add(path, RuleRequired, "is required")
+			return
+		}
+		if fp.omitEmpty {
+			return
+		}
+	}
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	for _, fc := range fp.checks {
+		if message := fc.check(value); message != "" {
+			out.Add(path, fc.rule, message)
+		}
+	}
+
+	if fp.nested == nil {
+		return
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		fp.nested.validate(value, path, out)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			elem := value.Index(i)
+			for elem.Kind() == reflect.Pointer {
+				if elem.IsNil() {
+					break
+				}
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct {
+				fp.nested.validate(elem, path+"["+strconv.Itoa(i)+"]", out)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package events_test
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type invoiceLine struct {
+	SKU      string `json:"sku" validate:"required,sku"`
+	Quantity int    `json:"quantity" validate:"gt=0"`
+}
+
+type invoice struct {
+	ID       string        `json:"id" validate:"required,uuid"`
+	Email    string        `json:"email" validate:"required,email"`
+	Currency string        `json:"currency" validate:"required,iso4217"`
+	Status   string        `json:"status" validate:"oneof=DRAFT ISSUED"`
+	Note     string        `json:"note,omitempty" validate:"omitempty,max=5"`
+	Total    float64       `json:"total" validate:"min=0,max=1000"`
+	Lines    []invoiceLine `json:"lines" validate:"max=2"`
+	Internal string        `json:"-" validate:"required"`
+}
+
+func init() {
+	events.RegisterRule("sku", func(param string) (events.Check, error) {
+		return func(value reflect.Value) string {
+			if !strings.HasPrefix(value.String(), "sku-") {
+				return "must start with sku-"
+			}
+			return ""
+		}, nil
+	})
+}
+
+func validInvoice() invoice {
+	return invoice{
+		ID:       "8f14e45f-ceea-4c3b-9e5a-2f0c1e9b7d21",
+		Email:    "billing@example.com",
+		Currency: "BRL",
+		Status:   "ISSUED",
+		Total:    10,
+		Lines:    []invoiceLine{{SKU: "sku-1", Quantity: 1}},
+	}
+}
+
+func issues(t *testing.T, err error) []events.ValidationIssue {
+	t.Helper()
+	var validationErr *events.ValidationError
+	if !assert.ErrorAs(t, err, &validationErr) {
+		return nil
+	}
+	return validationErr.Issues
+}
+
+func TestValidateStruct(t *testing.T) {
+	t.Run("accepts a valid struct", func(t *testing.T) {
+		v := validInvoice()
+		assert.NoError(t, events.ValidateStruct(&v))
+	})
+
+	t.Run("reports every rule with its path", func(t *testing.T) {
+		v := invoice{
+			ID:       "not-a-uuid",
+			Email:    "Billing <billing@example.com>",
+			Currency: "BRX",
+			Status:   "PAID",
+			Note:     "far too long",
+			Total:    1000.5,
+			Lines:    []invoiceLine{{SKU: "sku-1", Quantity: 1}, {Quantity: 0}, {SKU: "x", Quantity: 1}},
+		}
+
+		assert.Equal(t, []events.ValidationIssue{
+			{Path: "$.currency", Rule: events.RuleISO4217, Message: "must be an ISO 4217 currency code"},
+			{Path: "$.email", Rule: events.RuleEmail, Message: "must be a valid email address"},
+			{Path: "$.id", Rule: events.RuleUUID, Message: "must be a valid UUID"},
+			{Path: "$.lines", Rule: events.RuleMax, Message: "must be at most 2 items"},
+			{Path: "$.lines[1].quantity", Rule: events.RuleGreaterThan, Message: "must be greater than 0"},
+			{Path: "$.lines[1].sku", Rule: events.RuleRequired, Message: "is required"},
+			{Path: "$.lines[2].sku", Rule: "sku", Message: "must start with sku-"},
+			{Path: "$.note", Rule: events.RuleMax, Message: "must be at most 5 characters"},
+			{Path: "$.status", Rule: events.RuleOneOf, Message: "must be one of DRAFT, ISSUED"},
+			{Path: "$.total", Rule: events.RuleMax, Message: "must be at most 1000"},
+		}, issues(t, events.ValidateStruct(&v)))
+	})
+
+	t.Run("stops at required and skips omitempty", func(t *testing.T) {
+		v := validInvoice()
+		v.Email = ""
+		v.Note = ""
+
+		assert.Equal(t, []events.ValidationIssue{
+			{Path: "$.email", Rule: events.RuleRequired, Message: "is required"},
+		}, issues(t, events.ValidateStruct(&v)))
+	})
+
+	t.Run("rejects unknown rules and bad parameters", func(t *testing.T) {
+		err := events.ValidateStruct(&struct {
+			Name string `validate:"shiny"`
+		}{})
+		assert.ErrorContains(t, err, `unknown rule "shiny"`)
+
+		err = events.ValidateStruct(&struct {
+			Count int `validate:"gt=many"`
+		}{})
+		assert.ErrorContains(t, err, "parameter must be a number")
+	})
+
+	t.Run("rejects non structs", func(t *testing.T) {
+		assert.Error(t, events.ValidateStruct("nope"))
+		assert.Error(t, events.ValidateStruct((*invoice)(nil)))
+	})
+}
+
+func TestSchemaRegistry_RegisterChecksValidateTags(t *testing.T) {
+	registry := events.NewSchemaRegistry()
+	err := registry.Register("broken.event", "v1", func() events.Schema { return &brokenEvent{} })
+	assert.ErrorContains(t, err, "invalid validate tag on brokenEvent.Name")
+}
+
+type brokenEvent struct {
+	Name string `json:"name" validate:"required,nope"`
+}
+
+func (e *brokenEvent) Validate() error { return events.ValidateStruct(e) }
+
+func TestValidateStruct_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				v := validInvoice()
+				assert.NoError(t, events.ValidateStruct(&v))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationIssue is a single violation found in an event body. Path is a
+// JSON path such as "$.lines[0].quantity" and Rule a short code for the
+// check that failed, like "required".
+type ValidationIssue struct {
+	Path    string `json:"path" dynamodbav:"path"`
+	Rule    string `json:"rule" dynamodbav:"rule"`
+	Message string `json:"message" dynamodbav:"message"`
+}
+
+// ValidationError collects every violation in an event body, so producers
+// can fix them all in one round trip.
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, fmt.Sprintf("%s: %s", issue.Path, issue.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Add(path, rule, message string) {
+	e.Issues = append(e.Issues, ValidationIssue{Path: path, Rule: rule, Message: message})
+}
+
+// Err returns e when it holds any issue, sorted by path, and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Issues) == 0 {
+		return nil
+	}
+	sort.SliceStable(e.Issues, func(i, j int) bool { return e.Issues[i].Path < e.Issues[j].Path })
+	return e
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const reloadDebounce = 250 * time.Millisecond
+
+// WatchError is a failure of the file watcher itself, as opposed to a reload
+// that rejected the schemas it found. Changes may go unnoticed until it is
+// fixed.
+type WatchError struct {
+	Err error
+}
+
+func (e *WatchError) Error() string {
+	return "schema watcher failed: " + e.Err.Error()
+}
+
+func (e *WatchError) Unwrap() error {
+	return e.Err
+}
+
+// Watch reloads dir with LoadDir whenever something under it changes, until
+// ctx is done. Bursts of changes, like an editor saving a file or a deploy
+// copying several, are folded into a single reload. onReload receives the
+// outcome of every reload, and watcher errors as a *WatchError.
+func (r *SchemaRegistry) Watch(ctx context.Context, dir string, onReload func(err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watchTree(watcher, dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var reload <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// fsnotify does not watch recursively, so new event type
+				// directories must be added as they appear.
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := watchTree(watcher, event.Name); err != nil {
+							onReload(&WatchError{Err: err})
+						}
+					}
+				}
+				reload = time.After(reloadDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onReload(&WatchError{Err: err})
+			case <-reload:
+				reload = nil
+				onReload(r.LoadDir(dir))
+			}
+		}
+	}()
+
+	return nil
+}
+
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,82 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, dir, eventType, version, body string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, eventType), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, eventType, version+".json"), []byte(body), 0o644))
+}
+
+func TestSchemaRegistry_Watch(t *testing.T) {
+	dir := t.TempDir()
+	writeSchema := func(eventType, version, body string) {
+		writeFile(t, dir, eventType, version, body)
+	}
+	writeSchema("invoice.issued", "v1", `{"type": "object", "required": ["invoice_id"]}`)
+
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.LoadDir(dir))
+
+	var mu sync.Mutex
+	var results []error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, registry.Watch(ctx, dir, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, err)
+	}))
+
+	waitForReload := func(n int) error {
+		var last error
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(results) < n {
+				return false
+			}
+			last = results[len(results)-1]
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+		return last
+	}
+
+	t.Run("picks up a new event type", func(t *testing.T) {
+		writeSchema("refund.issued", "v1", `{"type": "object", "required": ["refund_id"]}`)
+		assert.NoError(t, waitForReload(1))
+
+		_, err := registry.Unmarshal("refund.issued", "v1", []byte(`{"refund_id": "r-1"}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("keeps the loaded schemas when a file is broken", func(t *testing.T) {
+		writeSchema("invoice.issued", "v1", `{"type": `)
+		err := waitForReload(2)
+		assert.Error(t, err)
+		var watchErr *events.WatchError
+		assert.False(t, errors.As(err, &watchErr), "a rejected schema is not a watcher error")
+
+		_, err = registry.Unmarshal("invoice.issued", "v1", []byte(`{}`))
+		assert.ErrorContains(t, err, "invoice_id")
+	})
+
+	t.Run("swaps in the fixed file", func(t *testing.T) {
+		writeSchema("invoice.issued", "v1", `{"type": "object"}`)
+		assert.NoError(t, waitForReload(3))
+
+		_, err := registry.Unmarshal("invoice.issued", "v1", []byte(`{}`))
+		assert.NoError(t, err)
+	})
+}
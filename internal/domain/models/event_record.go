@@ -1,5 +1,7 @@
 package models
 
+import "github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+
 type EventRecord struct {
 	ID            string `dynamodbav:"id"`
 	EventID       string `dynamodbav:"event_id"`
@@ -8,7 +10,13 @@ type EventRecord struct {
 	SchemaVersion string `dynamodbav:"schema_version"`
 	OccurredAt    string `dynamodbav:"occurred_at"`
 	Status        string `dynamodbav:"status"`
+	ProcessedAt   string `dynamodbav:"processed_at,omitempty"`
 	Body          string `dynamodbav:"body"`
+	UpcastVersion string `dynamodbav:"upcast_version,omitempty"`
+	UpcastBody    string `dynamodbav:"upcast_body,omitempty"`
+
+	ValidationIssues []events.ValidationIssue `dynamodbav:"validation_issues,omitempty"`
+	Warnings         []events.ValidationIssue `dynamodbav:"warnings,omitempty"`
 }
 
 func (e EventRecord) TableName() *string {
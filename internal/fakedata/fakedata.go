@@ -0,0 +1,282 @@
+// Package fakedata builds event bodies from the field descriptors of the
+// schema registry, so test traffic exists for every registered event type
+// and version without hand-written payloads.
+package fakedata
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+)
+
+// Currencies with two decimal places, so generated amounts pass the money
+// scale check whichever one is picked.
+var currencies = []string{"BRL", "USD", "EUR", "GBP"}
+
+type Generator struct {
+	faker  *gofakeit.Faker
+	fields map[string][]events.FieldDescriptor
+	// files holds the keys of JSON Schema files, which report a missing
+	// field on the object that lacks it rather than on the field.
+	files map[string]bool
+}
+
+// New reads the registered schemas once; schemas registered or loaded later
+// are not seen. A seed of 0 picks a random one.
+func New(registry *events.SchemaRegistry, seed int64) *Generator {
+	g := &Generator{
+		faker:  gofakeit.New(seed),
+		fields: make(map[string][]events.FieldDescriptor),
+		files:  make(map[string]bool),
+	}
+	for _, info := range registry.Schemas() {
+		g.fields[key(info.EventType, info.Version)] = info.Fields
+		g.files[key(info.EventType, info.Version)] = info.Source != "go"
+	}
+	return g
+}
+
+func key(eventType, version string) string {
+	return eventType + ":" + version
+}
+
+// Valid returns a body that passes validation for the event type and
+// version. Optional fields are always filled.
+func (g *Generator) Valid(eventType, version string) (map[string]any, error) {
+	fields, ok := g.fields[key(eventType, version)]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema version %s for event type %s", version, eventType)
+	}
+	return g.object(fields), nil
+}
+
+// Invalid returns a body with one field broken on purpose, and the issue
+// validation is expected to report for it.
+func (g *Generator) Invalid(eventType, version string) (map[string]any, events.ValidationIssue, error) {
+	body, err := g.Valid(eventType, version)
+	if err != nil {
+		return nil, events.ValidationIssue{}, err
+	}
+
+	missingPath := ""
+	if g.files[key(eventType, version)] {
+		missingPath = "$"
+	}
+
+	var breakers []func() events.ValidationIssue
+	for _, field := range g.fields[key(eventType, version)] {
+		breakers = append(breakers, g.breakers(body, field, missingPath)...)
+	}
+	if len(breakers) == 0 {
+		return nil, events.ValidationIssue{}, fmt.Errorf("%s %s has no field that can be made invalid", eventType, version)
+	}
+	return body, breakers[g.faker.Number(0, len(breakers)-1)](), nil
+}
+
+func (g *Generator) object(fields []events.FieldDescriptor) map[string]any {
+	body := make(map[string]any, len(fields))
+	for _, field := range fields {
+		body[field.Name] = g.value(field)
+	}
+	return body
+}
+
+func (g *Generator) value(field events.FieldDescriptor) any {
+	if len(field.Enum) > 0 {
+		return g.faker.RandomString(field.Enum)
+	}
+
+	switch field.Type {
+	case "string":
+		return g.text(field)
+	case "integer":
+		low, high := bounds(field, 1, 10)
+		return g.faker.Number(int(math.Ceil(low)), int(high))
+	case "number":
+		low, high := bounds(field, 1, 1000)
+		return math.Round(g.faker.Float64Range(math.Max(low, 0.01), high)*100) / 100
+	case "boolean":
+		return g.faker.Bool()
+	case "array":
+		var items []any
+		for i := g.faker.Number(1, 3); i > 0; i-- {
+			if field.Items != nil {
+				items = append(items, g.value(*field.Items))
+			}
+		}
+		return items
+	case "object":
+		return g.object(field.Fields)
+	}
+	return g.faker.Word()
+}
+
+func (g *Generator) text(field events.FieldDescriptor) string {
+	switch field.Format {
+	case "email":
+		return g.faker.Email()
+	case "uuid":
+		return g.faker.UUID()
+	case "date-time":
+		return g.faker.DateRange(time.Now().AddDate(-1, 0, 0), time.Now()).UTC().Format(time.RFC3339)
+	}
+
+	for _, rule := range field.Rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case events.RuleID:
+			prefix, _, _ := strings.Cut(param, " ")
+			return fmt.Sprintf("%s-%d", prefix, g.faker.Number(1000, 999999))
+		case events.RuleISO4217:
+			return g.faker.RandomString(currencies)
+		case events.RuleOneOf:
+			return g.faker.RandomString(strings.Fields(param))
+		}
+	}
+
+	var s string
+	switch {
+	case strings.Contains(field.Name, "name"):
+		s = g.faker.Name()
+	case strings.Contains(field.Name, "currency"):
+		s = g.faker.RandomString(currencies)
+	default:
+		s = g.faker.Word()
+	}
+
+	low, high := bounds(field, 1, 0)
+	for len(s) < int(low) {
+		s += g.faker.Letter()
+	}
+	if high > 0 && len(s) > int(high) {
+		s = s[:int(high)]
+	}
+	return s
+}
+
+// bounds reads gt, min and max rules as a range, falling back to low and
+// high when the field has none. gt on a whole value is moved up by one.
+func bounds(field events.FieldDescriptor, low, high float64) (float64, float64) {
+	for _, rule := range field.Rules {
+		name, param, _ := strings.Cut(rule, "=")
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			continue
+		}
+		switch name {
+		case events.RuleGreaterThan:
+			if field.Type == "number" {
+				low = math.Max(low, n+0.01)
+			} else {
+				low = math.Max(low, n+1)
+			}
+		case events.RuleMin:
+			low = math.Max(low, n)
+		case events.RuleMax:
+			high = n
+		}
+	}
+	if high != 0 && high < low {
+		low = high
+	}
+	if high == 0 && field.Type != "string" {
+		high = low + 1000
+	}
+	return low, high
+}
+
+// breakers lists the ways field can be made invalid in body. Each one
+// changes body and returns the issue validation should report. A removed
+// required field is reported at missingPath when it is set.
+func (g *Generator) breakers(body map[string]any, field events.FieldDescriptor, missingPath string) []func() events.ValidationIssue {
+	path := "$." + field.Name
+	issue := func(rule string) events.ValidationIssue {
+		return events.ValidationIssue{Path: path, Rule: rule}
+	}
+
+	var out []func() events.ValidationIssue
+	if field.Required {
+		out = append(out, func() events.ValidationIssue {
+			delete(body, field.Name)
+			if missingPath != "" {
+				return events.ValidationIssue{Path: missingPath, Rule: events.RuleRequired}
+			}
+			return issue(events.RuleRequired)
+		})
+	}
+	if len(field.Enum) > 0 {
+		out = append(out, func() events.ValidationIssue {
+			body[field.Name] = "NOT_" + field.Enum[0]
+			return issue(events.RuleEnum)
+		})
+	}
+	switch field.Format {
+	case "email":
+		out = append(out, func() events.ValidationIssue {
+			body[field.Name] = g.faker.Username()
+			return issue(formatRule(field))
+		})
+	case "uuid":
+		out = append(out, func() events.ValidationIssue {
+			body[field.Name] = g.faker.Word()
+			return issue(formatRule(field))
+		})
+	}
+
+	for _, rule := range field.Rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case events.RuleID:
+			out = append(out, func() events.ValidationIssue {
+				body[field.Name] = "bad id " + strconv.Itoa(g.faker.Number(1, 999))
+				return issue(events.RuleID)
+			})
+		case events.RuleISO4217:
+			out = append(out, func() events.ValidationIssue {
+				body[field.Name] = "XYZ"
+				return issue(events.RuleISO4217)
+			})
+		case events.RuleGreaterThan:
+			if field.Type != "number" && field.Type != "integer" {
+				continue
+			}
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			out = append(out, func() events.ValidationIssue {
+				body[field.Name] = n - float64(g.faker.Number(1, 100))
+				return issue(events.RuleGreaterThan)
+			})
+		case events.RuleMax:
+			if field.Type != "string" {
+				continue
+			}
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			out = append(out, func() events.ValidationIssue {
+				body[field.Name] = strings.Repeat("x", n+1)
+				return issue(events.RuleMax)
+			})
+		}
+	}
+	return out
+}
+
+// formatRule names the rule a broken format is reported under: the validate
+// rule for Go schemas, the format keyword for JSON Schema files.
+func formatRule(field events.FieldDescriptor) string {
+	for _, rule := range field.Rules {
+		if rule == field.Format {
+			return rule
+		}
+	}
+	return "format"
+}
@@ -0,0 +1,74 @@
+package fakedata_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/guilherme-daniel-rs/event-processor/internal/domain/events"
+	"github.com/guilherme-daniel-rs/event-processor/internal/fakedata"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRegistry(t *testing.T) *events.SchemaRegistry {
+	t.Helper()
+	registry := events.NewSchemaRegistry()
+	assert.NoError(t, registry.LoadDir("../../schemas"))
+	return registry
+}
+
+func TestGenerator_Valid(t *testing.T) {
+	registry := newRegistry(t)
+	generator := fakedata.New(registry, 42)
+
+	for _, info := range registry.Schemas() {
+		t.Run(info.EventType+" "+info.Version, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				body, err := generator.Valid(info.EventType, info.Version)
+				assert.NoError(t, err)
+
+				data, err := json.Marshal(body)
+				assert.NoError(t, err)
+				_, err = registry.Unmarshal(info.EventType, info.Version, data)
+				if !assert.NoError(t, err, string(data)) {
+					return
+				}
+			}
+		})
+	}
+
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := generator.Valid("user.created", "v9")
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerator_Invalid(t *testing.T) {
+	registry := newRegistry(t)
+	generator := fakedata.New(registry, 7)
+
+	for _, info := range registry.Schemas() {
+		t.Run(info.EventType+" "+info.Version, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				body, want, err := generator.Invalid(info.EventType, info.Version)
+				assert.NoError(t, err)
+
+				data, err := json.Marshal(body)
+				assert.NoError(t, err)
+				_, err = registry.Unmarshal(info.EventType, info.Version, data)
+
+				var validationErr *events.ValidationError
+				if !assert.True(t, errors.As(err, &validationErr), string(data)) {
+					return
+				}
+				var got []events.ValidationIssue
+				for _, issue := range validationErr.Issues {
+					got = append(got, events.ValidationIssue{Path: issue.Path, Rule: issue.Rule})
+				}
+				if !assert.Equal(t, []events.ValidationIssue{want}, got, string(data)) {
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the lag histograms.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600}
+
+// Histogram counts observations in cumulative buckets, so a bucket holds
+// every value up to its bound, and publishes them as JSON through expvar.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make(map[string]uint64, len(h.bounds)+1)
+	for i, bound := range h.bounds {
+		buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = h.counts[i]
+	}
+	buckets["+Inf"] = h.count
+
+	data, _ := json.Marshal(struct {
+		Buckets map[string]uint64 `json:"buckets"`
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+	}{buckets, h.count, math.Round(h.sum*1000) / 1000})
+	return string(data)
+}
+
+var histogramsMu sync.Mutex
+
+// ObserveDuration adds d, in seconds, to the histogram stored under key in
+// m, creating it with LatencyBuckets on first use.
+func ObserveDuration(m *expvar.Map, key string, d time.Duration) {
+	histogramsMu.Lock()
+	h, ok := m.Get(key).(*Histogram)
+	if !ok {
+		h = NewHistogram(LatencyBuckets)
+		m.Set(key, h)
+	}
+	histogramsMu.Unlock()
+
+	h.Observe(d.Seconds())
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	assert.JSONEq(t, `{"buckets": {"0.1": 1, "1": 2, "+Inf": 3}, "count": 3, "sum": 2.55}`, h.String())
+}
+
+func TestObserveDuration(t *testing.T) {
+	m := new(expvar.Map).Init()
+	ObserveDuration(m, "user.created", 20*time.Millisecond)
+	ObserveDuration(m, "user.created", 2*time.Second)
+
+	h := m.Get("user.created").(*Histogram)
+	assert.Equal(t, uint64(2), h.count)
+	assert.Equal(t, uint64(1), h.counts[2])
+}
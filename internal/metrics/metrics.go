@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+var (
+	MessagesReceived   = expvar.NewMap("messages_received")
+	MessagesAcked      = expvar.NewMap("messages_acked")
+	MessagesRetried    = expvar.NewMap("messages_retried")
+	MessagesDeadLetter = expvar.NewMap("messages_dead_lettered")
+	MessagesInFlight   = expvar.NewMap("messages_in_flight")
+	ReceiveErrors      = expvar.NewMap("receive_errors")
+
+	TenantAllowed   = expvar.NewMap("tenant_messages_allowed")
+	TenantThrottled = expvar.NewMap("tenant_messages_throttled")
+
+	CircuitBreakerState       = expvar.NewMap("circuit_breaker_state")
+	CircuitBreakerTransitions = expvar.NewMap("circuit_breaker_transitions")
+	MessagesCircuitOpen       = expvar.NewMap("messages_deferred_circuit_open")
+
+	ConcurrencyLimit = expvar.NewMap("adaptive_concurrency_limit")
+
+	BlobDeleteErrors = expvar.NewMap("blob_delete_errors")
+
+	EventsQuarantined = expvar.NewMap("events_quarantined")
+
+	// SchemaReloads counts hot reloads of the schema directory as applied or
+	// rejected, and failures of the watcher itself as watch_error.
+	SchemaReloads = expvar.NewMap("schema_reloads")
+
+	// IngestLag is the time from the producer's sent-at attribute to the
+	// record being stored, and EventLag the time from occurred_at, both per
+	// event type.
+	IngestLag = expvar.NewMap("ingest_lag_seconds")
+	EventLag  = expvar.NewMap("event_lag_seconds")
+)
+
+func Handler() http.Handler {
+	return expvar.Handler()
+}
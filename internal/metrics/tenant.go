@@ -0,0 +1,30 @@
+package metrics
+
+import "sync"
+
+// MaxTenantKeys bounds the tenant maps, whose keys come from message bodies.
+// Tenants seen after the first MaxTenantKeys are counted under OtherTenant.
+const MaxTenantKeys = 100
+
+const OtherTenant = "other"
+
+var tenantKeys = struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}{seen: make(map[string]bool)}
+
+// TenantKey returns the key tenant is counted under in TenantAllowed and
+// TenantThrottled.
+func TenantKey(tenant string) string {
+	tenantKeys.mu.Lock()
+	defer tenantKeys.mu.Unlock()
+
+	if tenantKeys.seen[tenant] {
+		return tenant
+	}
+	if len(tenantKeys.seen) >= MaxTenantKeys {
+		return OtherTenant
+	}
+	tenantKeys.seen[tenant] = true
+	return tenant
+}
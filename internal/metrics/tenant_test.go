@@ -0,0 +1,18 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantKey(t *testing.T) {
+	for i := 0; i < MaxTenantKeys; i++ {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		assert.Equal(t, tenant, TenantKey(tenant))
+	}
+
+	assert.Equal(t, OtherTenant, TenantKey("tenant-late"))
+	assert.Equal(t, "tenant-0", TenantKey("tenant-0"))
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "context"
+
+type BlobStore interface {
+	// Get returns ErrBlobTooLarge for a blob over maxSize bytes without
+	// reading all of it. A maxSize of zero or less means no limit.
+	Get(ctx context.Context, bucket, key string, maxSize int64) ([]byte, error)
+	Put(ctx context.Context, bucket, key string, data []byte) error
+	Delete(ctx context.Context, bucket, key string) error
+}
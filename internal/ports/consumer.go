@@ -3,13 +3,43 @@ package ports
 import "context"
 
 type Message struct {
-	ID           string
-	Body         []byte
-	Attributes   map[string]string
-	AckToken     string
-	ReceiveCount int
+	ID                string
+	Body              []byte
+	Attributes        map[string]string
+	MessageAttributes map[string]string
+	AckToken          string
+	ReceiveCount      int
 }
 
 type Consumer interface {
 	Read(ctx context.Context, process func(ctx context.Context, msg Message) error) error
 }
+
+type RunState string
+
+const (
+	RunStateRunning  RunState = "running"
+	RunStatePaused   RunState = "paused"
+	RunStateDraining RunState = "draining"
+	RunStateStopped  RunState = "stopped"
+)
+
+type InFlightMessage struct {
+	ID         string  `json:"id"`
+	Attempt    int     `json:"attempt"`
+	AgeSeconds float64 `json:"age_seconds"`
+}
+
+type ConsumerStatus struct {
+	Name     string            `json:"name"`
+	State    RunState          `json:"state"`
+	InFlight []InFlightMessage `json:"in_flight"`
+}
+
+type ConsumerControl interface {
+	Name() string
+	Pause() error
+	Resume() error
+	Drain()
+	Status() ConsumerStatus
+}
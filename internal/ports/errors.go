@@ -22,3 +22,11 @@ func IsNonRetriable(err error) bool {
 	var nonRetriable *NonRetriableError
 	return errors.As(err, &nonRetriable)
 }
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+var ErrThrottled = errors.New("downstream is throttling requests")
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+var ErrBlobTooLarge = errors.New("blob exceeds the maximum size")
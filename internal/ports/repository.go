@@ -9,3 +9,8 @@ import (
 type EventRepository interface {
 	Save(ctx context.Context, event models.EventRecord) error
 }
+
+// EventFinder looks up stored records by the producer's event_id.
+type EventFinder interface {
+	FindByEventID(ctx context.Context, eventID string) ([]models.EventRecord, error)
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0
+}
+
+type bucket struct {
+	limit   Limit
+	tokens  float64
+	updated time.Time
+}
+
+// sweepInterval is how often Allow drops the buckets that have refilled. A
+// full bucket behaves like a new one, so dropping it only frees memory held
+// for keys that went quiet.
+const sweepInterval = time.Minute
+
+// Limiter keeps one token bucket per key. Keys without an override share the
+// default limit, and a zero rate disables limiting for that key.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	swept     time.Time
+	defaults  Limit
+	overrides map[string]Limit
+	now       func() time.Time
+}
+
+func NewLimiter(defaults Limit, overrides map[string]Limit) *Limiter {
+	return &Limiter{
+		buckets:   make(map[string]*bucket),
+		defaults:  defaults,
+		overrides: overrides,
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token for key. When none is left it reports how long until
+// the next one is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	limit := l.limitFor(key)
+	if limit.unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limit: limit, tokens: float64(burst(limit)), updated: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(burst(limit)), b.tokens+elapsed*limit.Rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	missing := 1 - b.tokens
+	return false, time.Duration(missing / limit.Rate * float64(time.Second))
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate >= float64(burst(b.limit)) {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+func (l *Limiter) limitFor(key string) Limit {
+	if limit, ok := l.overrides[key]; ok {
+		return limit
+	}
+	return l.defaults
+}
+
+func burst(limit Limit) int {
+	if limit.Burst <= 0 {
+		return max(1, int(math.Ceil(limit.Rate)))
+	}
+	return limit.Burst
+}
+
+// ParseOverrides reads overrides written as "tenant-1=10:20,tenant-2=5",
+// where each value is rate per second and an optional burst.
+func ParseOverrides(s string) (map[string]Limit, error) {
+	overrides := map[string]Limit{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid rate limit override: %s", entry)
+		}
+
+		rate, burstValue, hasBurst := strings.Cut(value, ":")
+		limit := Limit{}
+
+		var err error
+		if limit.Rate, err = strconv.ParseFloat(rate, 64); err != nil {
+			return nil, fmt.Errorf("invalid rate for %s: %w", key, err)
+		}
+		if hasBurst {
+			if limit.Burst, err = strconv.Atoi(burstValue); err != nil {
+				return nil, fmt.Errorf("invalid burst for %s: %w", key, err)
+			}
+		}
+
+		overrides[strings.TrimSpace(key)] = limit
+	}
+	return overrides, nil
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewLimiter(Limit{Rate: 2, Burst: 2}, map[string]Limit{
+		"tenant-vip":  {Rate: 10, Burst: 5},
+		"tenant-free": {Rate: 0},
+	})
+	limiter.now = func() time.Time { return now }
+
+	t.Run("allows up to the burst then throttles", func(t *testing.T) {
+		allowed, _ := limiter.Allow("tenant-1")
+		assert.True(t, allowed)
+		allowed, _ = limiter.Allow("tenant-1")
+		assert.True(t, allowed)
+
+		allowed, wait := limiter.Allow("tenant-1")
+		assert.False(t, allowed)
+		assert.Equal(t, 500*time.Millisecond, wait)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+		allowed, _ := limiter.Allow("tenant-1")
+		assert.True(t, allowed)
+		allowed, _ = limiter.Allow("tenant-1")
+		assert.False(t, allowed)
+	})
+
+	t.Run("keeps tenants independent", func(t *testing.T) {
+		allowed, _ := limiter.Allow("tenant-2")
+		assert.True(t, allowed)
+	})
+
+	t.Run("applies overrides", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			allowed, _ := limiter.Allow("tenant-vip")
+			assert.True(t, allowed)
+		}
+		allowed, _ := limiter.Allow("tenant-vip")
+		assert.False(t, allowed)
+
+		for i := 0; i < 100; i++ {
+			allowed, _ := limiter.Allow("tenant-free")
+			assert.True(t, allowed)
+		}
+	})
+}
+
+func TestLimiter_DropsIdleBuckets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewLimiter(Limit{Rate: 1, Burst: 2}, nil)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("idle")
+	limiter.Allow("busy")
+	limiter.Allow("busy")
+
+	now = now.Add(sweepInterval)
+	limiter.Allow("busy")
+	limiter.Allow("busy")
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "busy")
+
+	now = now.Add(sweepInterval)
+	limiter.Allow("other")
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "other")
+}
+
+func TestParseOverrides(t *testing.T) {
+	overrides, err := ParseOverrides("tenant-1=10:20, tenant-2=5,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		"tenant-1": {Rate: 10, Burst: 20},
+		"tenant-2": {Rate: 5},
+	}, overrides)
+
+	for _, invalid := range []string{"tenant-1", "=5", "tenant-1=fast", "tenant-1=5:big"} {
+		_, err := ParseOverrides(invalid)
+		assert.Error(t, err, invalid)
+	}
+}